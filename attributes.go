@@ -39,24 +39,32 @@ func (e Element) GetAttributeNodeNS(namespace, key string) (html.Attribute, bool
 
 // SetAttribute sets the value of an attribute on the element
 func (e Element) SetAttribute(key string, value string) {
-	attr.Set(e.Node, key, value)
+	mutateAttr(e.Node, "*", key, func() {
+		attr.Set(e.Node, key, value)
+	})
 }
 
 // SetAttributeNode sets the attribute.
 // if already exist the key, it attribute overridden
 func (e Element) SetAttributeNode(a html.Attribute) {
-	attr.SetNode(e.Node, a)
+	mutateAttr(e.Node, "*", a.Key, func() {
+		attr.SetNode(e.Node, a)
+	})
 }
 
 // SetAttributeNS sets the value of an attribute
 // with the specified namespace and name
 func (e Element) SetAttributeNS(namespace, key, value string) {
-	attr.SetNS(e.Node, namespace, key, value)
+	mutateAttr(e.Node, namespace, key, func() {
+		attr.SetNS(e.Node, namespace, key, value)
+	})
 }
 
 // SetAttributeNodeNS sets the namespaced attribute node on the element
 func (e Element) SetAttributeNodeNS(a html.Attribute) {
-	attr.SetNodeNS(e.Node, a)
+	mutateAttr(e.Node, a.Namespace, a.Key, func() {
+		attr.SetNodeNS(e.Node, a)
+	})
 }
 
 // HasAttributes returns the bool value indicating whether element has attributes
@@ -77,17 +85,23 @@ func (e Element) HasAttributeNS(namespace, key string) bool {
 
 // RemoveAttribute
 func (e Element) RemoveAttribute(key string) {
-	attr.Remove(e.Node, key)
+	mutateAttr(e.Node, "*", key, func() {
+		attr.Remove(e.Node, key)
+	})
 }
 
 // RemoveAttributeNS
 func (e Element) RemoveAttributeNS(namespace, key string) {
-	attr.RemoveNS(e.Node, namespace, key)
+	mutateAttr(e.Node, namespace, key, func() {
+		attr.RemoveNS(e.Node, namespace, key)
+	})
 }
 
 // RemoveAttributeNode
 func (e Element) RemoveAttributeNode(a html.Attribute) {
-	attr.RemoveNode(e.Node, a)
+	mutateAttr(e.Node, a.Namespace, a.Key, func() {
+		attr.RemoveNode(e.Node, a)
+	})
 }
 
 // DOMTokenList
@@ -0,0 +1,422 @@
+package gohtml
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/saihon/gohtml/attr"
+	"github.com/saihon/gohtml/find"
+)
+
+// controlState is the current state of a form control or an option,
+// which is not reflected to the attributes like the value of JavaScript.
+// the default state is given by the attributes until it gets dirty
+type controlState struct {
+	value         string
+	dirtyValue    bool
+	checked       bool
+	dirtyChecked  bool
+	selected      bool
+	dirtySelected bool
+	// customError is the message set by SetCustomValidity
+	customError string
+}
+
+// controls is the state of the controls guarded by docMu. it is keyed
+// by the control itself, so the state follows the control removed and
+// inserted again, or moved to another document. it is kept until
+// Release of the document that the control belongs to, so the state of
+// the document dropped without Release is never freed
+var controls map[*html.Node]*controlState
+
+// stateOf returns the state of the control, or nil if it is not dirty
+func stateOf(n *html.Node) *controlState {
+	docMu.Lock()
+	defer docMu.Unlock()
+	return controls[n]
+}
+
+// ensureState returns the state of the control and creates it if not exist
+func ensureState(n *html.Node) *controlState {
+	docMu.Lock()
+	defer docMu.Unlock()
+	if controls == nil {
+		controls = make(map[*html.Node]*controlState)
+	}
+	s := controls[n]
+	if s == nil {
+		s = &controlState{}
+		controls[n] = s
+	}
+	return s
+}
+
+// releaseStates drops the state of the controls in the tree of n.
+// docMu must be locked
+func releaseStates(n *html.Node) {
+	if len(controls) == 0 {
+		return
+	}
+	delete(controls, n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		releaseStates(c)
+	}
+}
+
+// valueMode returns the value mode of <input>, "value", "default",
+// "default/on" or "filename"
+func valueMode(n *html.Node) string {
+	switch inputType(n) {
+	case "hidden", "submit", "image", "reset", "button":
+		return "default"
+	case "checkbox", "radio":
+		return "default/on"
+	case "file":
+		return "filename"
+	}
+	return "value"
+}
+
+// Value set or get the current value of the form control like value of
+// JavaScript. <input> and <textarea> keep the value set apart from the
+// attribute or the text, which remain the default value. <select> selects
+// the first option that has the value. it returns empty string for the
+// elements that are not a form control or an option. the value set is
+// kept until Release of the document that the element belongs to,
+// and is not freed if the document is dropped without Release
+func (e Element) Value(value ...string) string {
+	n := e.Node
+	if n.Type != html.ElementNode {
+		return ""
+	}
+	switch n.DataAtom {
+	case atom.Input:
+		switch valueMode(n) {
+		case "default":
+			if value != nil {
+				e.SetAttribute("value", strings.Join(value, ""))
+			}
+			return attr.Get(n, "value")
+		case "default/on":
+			if value != nil {
+				e.SetAttribute("value", strings.Join(value, ""))
+			}
+			if a, ok := attr.GetNode(n, "value"); ok {
+				return a.Val
+			}
+			return "on"
+		case "filename":
+			return ""
+		}
+	case atom.Textarea:
+	case atom.Select:
+		if value != nil {
+			selectValue(n, strings.Join(value, ""))
+		}
+		if list := selectedOptions(n); len(list) > 0 {
+			return optionValue(list[0])
+		}
+		return ""
+	case atom.Option:
+		if value != nil {
+			e.SetAttribute("value", strings.Join(value, ""))
+		}
+		return optionValue(n)
+	default:
+		return ""
+	}
+
+	if value != nil {
+		s := ensureState(n)
+		s.value = sanitizeValue(n, strings.Join(value, ""))
+		s.dirtyValue = true
+	}
+	return controlValue(n)
+}
+
+// DefaultValue returns the default value of <input> or <textarea>,
+// which is the value attribute or the text of <textarea>
+func (e Element) DefaultValue() string {
+	if e.Node.DataAtom == atom.Textarea {
+		return textareaText(e.Node)
+	}
+	return attr.Get(e.Node, "value")
+}
+
+// controlValue returns the current value of <input> or <textarea>
+func controlValue(n *html.Node) string {
+	if s := stateOf(n); s != nil && s.dirtyValue {
+		return s.value
+	}
+	if n.DataAtom == atom.Textarea {
+		return textareaText(n)
+	}
+	return sanitizeValue(n, attr.Get(n, "value"))
+}
+
+// textareaText returns the default value of <textarea>.
+// the newline just after the start tag is dropped by the parser
+func textareaText(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	}
+	return b.String()
+}
+
+// Checked set or get the current checkedness of the checkbox or the
+// radio button. checking a radio button unchecks the others in its group.
+// the checked attribute remains the default checkedness. the checkedness
+// set is kept until Release of the document that the element belongs to,
+// and is not freed if the document is dropped without Release
+func (e Element) Checked(checked ...bool) bool {
+	n := e.Node
+	if n.Type != html.ElementNode || n.DataAtom != atom.Input {
+		return false
+	}
+	if t := inputType(n); t != "checkbox" && t != "radio" {
+		return false
+	}
+	if checked != nil {
+		setChecked(n, checked[0])
+	}
+	return checkedness(n)
+}
+
+// DefaultChecked returns true if the element has the checked attribute
+func (e Element) DefaultChecked() bool {
+	return attr.Has(e.Node, "checked")
+}
+
+func setChecked(n *html.Node, checked bool) {
+	if checked && inputType(n) == "radio" {
+		for _, r := range radioGroup(n) {
+			s := ensureState(r)
+			s.checked, s.dirtyChecked = false, true
+		}
+	}
+	s := ensureState(n)
+	s.checked, s.dirtyChecked = checked, true
+}
+
+// radioGroup returns the radio buttons in the same group as n, including n
+func radioGroup(n *html.Node) []*html.Node {
+	name := attr.Get(n, "name")
+	if name == "" {
+		return []*html.Node{n}
+	}
+	owner := formOwner(n)
+	return find.All(rootOf(n), func(c *html.Node) bool {
+		return c.Type == html.ElementNode && c.DataAtom == atom.Input &&
+			inputType(c) == "radio" && attr.Get(c, "name") == name && formOwner(c) == owner
+	})
+}
+
+// SelectedOptions returns the selected options of <select>
+func (e Element) SelectedOptions() Collection {
+	if e.Node.Type != html.ElementNode || e.Node.DataAtom != atom.Select {
+		return Collection{}
+	}
+	return Collection{Nodes: selectedOptions(e.Node)}
+}
+
+// Selected set or get the current selectedness of <option>.
+// selecting an option of a single select unselects the others.
+// the selectedness set is kept until Release of the document
+// that the element belongs to, and is not freed without Release
+func (e Element) Selected(selected ...bool) bool {
+	n := e.Node
+	if n.Type != html.ElementNode || n.DataAtom != atom.Option {
+		return false
+	}
+	sel := selectOf(n)
+	if selected != nil {
+		if selected[0] && sel != nil && !attr.Has(sel, "multiple") {
+			for _, o := range options(sel) {
+				s := ensureState(o)
+				s.selected, s.dirtySelected = false, true
+			}
+		}
+		s := ensureState(n)
+		s.selected, s.dirtySelected = selected[0], true
+	}
+	if sel == nil {
+		return optionSelected(n)
+	}
+	for _, o := range selectedOptions(sel) {
+		if o == n {
+			return true
+		}
+	}
+	return false
+}
+
+// selectOf returns the <select> of the option
+func selectOf(n *html.Node) *html.Node {
+	p := n.Parent
+	if p != nil && p.DataAtom == atom.Optgroup {
+		p = p.Parent
+	}
+	if p != nil && p.Type == html.ElementNode && p.DataAtom == atom.Select {
+		return p
+	}
+	return nil
+}
+
+// optionSelected returns the selectedness of the option
+// without the rules of <select>
+func optionSelected(n *html.Node) bool {
+	if s := stateOf(n); s != nil && s.dirtySelected {
+		return s.selected
+	}
+	return attr.Has(n, "selected")
+}
+
+// selectValue selects the first option that has the value and
+// unselects the others, nothing is selected if no option has it
+func selectValue(sel *html.Node, value string) {
+	found := false
+	for _, o := range options(sel) {
+		s := ensureState(o)
+		s.selected = !found && optionValue(o) == value
+		s.dirtySelected = true
+		found = found || s.selected
+	}
+}
+
+// Reset restores the default values of the controls of the form
+func (f Form) Reset() {
+	for _, n := range f.listed() {
+		resetState(n)
+		if n.DataAtom == atom.Select {
+			for _, o := range options(n) {
+				resetState(o)
+			}
+		}
+	}
+}
+
+// resetState drops the current state except the custom validity
+func resetState(n *html.Node) {
+	docMu.Lock()
+	defer docMu.Unlock()
+	if s := controls[n]; s != nil && s.customError != "" {
+		controls[n] = &controlState{customError: s.customError}
+		return
+	}
+	delete(controls, n)
+}
+
+var (
+	reFloat         = regexp.MustCompile(`^-?(\d+|\d*\.\d+)([eE][-+]?\d+)?$`)
+	reColor         = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+	reWeek          = regexp.MustCompile(`^(\d{4,})-W(\d\d)$`)
+	reTime          = regexp.MustCompile(`^\d\d:\d\d(:\d\d(\.\d{1,3})?)?$`)
+	newlineReplacer = strings.NewReplacer("\r", "", "\n", "")
+)
+
+// parseNumber parses the valid floating-point number of HTML
+func parseNumber(s string) (float64, bool) {
+	if !reFloat.MatchString(s) {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+// parseTime parses the value of the date and time input types
+// as UTC. the week is the time of the Monday of the week
+func parseTime(typ, s string) (time.Time, bool) {
+	var layouts []string
+	switch typ {
+	case "date":
+		layouts = []string{"2006-01-02"}
+	case "month":
+		layouts = []string{"2006-01"}
+	case "time":
+		if !reTime.MatchString(s) {
+			return time.Time{}, false
+		}
+		layouts = []string{"15:04", "15:04:05", "15:04:05.999"}
+	case "datetime-local":
+		s = strings.Replace(s, " ", "T", 1)
+		i := strings.IndexByte(s, 'T')
+		if i < 0 || !reTime.MatchString(s[i+1:]) {
+			return time.Time{}, false
+		}
+		layouts = []string{"2006-01-02T15:04", "2006-01-02T15:04:05", "2006-01-02T15:04:05.999"}
+	case "week":
+		m := reWeek.FindStringSubmatch(s)
+		if m == nil {
+			return time.Time{}, false
+		}
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		// the week 1 has the first Thursday of the year
+		jan4 := time.Date(year, 1, 4, 0, 0, 0, 0, time.UTC)
+		monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(week-1)*7)
+		if _, w := monday.ISOWeek(); week < 1 || w != week {
+			return time.Time{}, false
+		}
+		return monday, true
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sanitizeValue returns the value sanitized by the value sanitization
+// algorithm of the type of <input>. invalid values become empty string
+func sanitizeValue(n *html.Node, v string) string {
+	if n.DataAtom != atom.Input {
+		return v
+	}
+	switch t := inputType(n); t {
+	case "text", "search", "tel", "password":
+		return newlineReplacer.Replace(v)
+	case "url", "email":
+		return strings.TrimSpace(newlineReplacer.Replace(v))
+	case "number":
+		if _, ok := parseNumber(v); ok {
+			return v
+		}
+		return ""
+	case "range":
+		if _, ok := parseNumber(v); ok {
+			return v
+		}
+		lo, _ := parseNumber(attr.Get(n, "min"))
+		hi, ok := parseNumber(attr.Get(n, "max"))
+		if !ok {
+			hi = 100
+		}
+		if hi < lo {
+			return strconv.FormatFloat(lo, 'f', -1, 64)
+		}
+		return strconv.FormatFloat(lo+(hi-lo)/2, 'f', -1, 64)
+	case "color":
+		if reColor.MatchString(v) {
+			return strings.ToLower(v)
+		}
+		return "#000000"
+	case "date", "month", "week", "time", "datetime-local":
+		if _, ok := parseTime(t, v); ok {
+			if t == "datetime-local" {
+				return strings.Replace(v, " ", "T", 1)
+			}
+			return v
+		}
+		return ""
+	}
+	return v
+}
@@ -0,0 +1,130 @@
+package gohtml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<form>
+<input id="text" value="a&#10;b">
+<input id="num" type="number" value="x">
+<input id="range" type="range" min="10" max="20">
+<input id="color" type="color" value="#ABCDEF">
+<input id="hidden" type="hidden" value="h">
+<input id="check" type="checkbox">
+<textarea id="ta">
+default</textarea>
+<select id="sel"><option disabled>d</option><option value="1">one</option><option>two</option></select>
+<select id="none" size="2"><option>x</option></select>
+</form>`))
+	get := func(id string) *Element { return doc.GetElementById(id) }
+
+	tests := []struct {
+		id, expect string
+	}{
+		{"text", "ab"},
+		{"num", ""},
+		{"range", "15"},
+		{"color", "#abcdef"},
+		{"hidden", "h"},
+		{"check", "on"},
+		{"ta", "default"},
+		{"sel", "1"},
+		{"none", ""},
+	}
+	for _, test := range tests {
+		if actual := get(test.id).Value(); actual != test.expect {
+			t.Errorf("\n%s: got : %q, want: %q\n", test.id, actual, test.expect)
+		}
+	}
+
+	text := get("text")
+	text.Value("new")
+	if text.Value() != "new" || text.DefaultValue() != "a\nb" || text.GetAttribute("value") != "a\nb" {
+		t.Errorf("\nthe value is reflected to the attribute: %q %q\n", text.Value(), text.DefaultValue())
+	}
+	get("hidden").Value("h2")
+	if actual := get("hidden").GetAttribute("value"); actual != "h2" {
+		t.Errorf("\ngot : %q, want: %q\n", actual, "h2")
+	}
+	get("ta").Value("typed")
+	if get("ta").Value() != "typed" || get("ta").DefaultValue() != "default" {
+		t.Errorf("\nthe textarea value is wrong: %q\n", get("ta").Value())
+	}
+
+	sel := get("sel")
+	if sel.Value("two"); sel.Value() != "two" || sel.SelectedOptions().Length() != 1 {
+		t.Errorf("\ngot : %q\n", sel.Value())
+	}
+	if sel.Value("nope"); sel.Value() != "" || sel.SelectedOptions().Length() != 0 {
+		t.Errorf("\nan option is selected: %q\n", sel.Value())
+	}
+
+	doc.Form().Get(0).Form().Reset()
+	if text.Value() != "ab" || sel.Value() != "1" || get("ta").Value() != "default" {
+		t.Errorf("\nnot reset: %q %q %q\n", text.Value(), sel.Value(), get("ta").Value())
+	}
+}
+
+func TestValueDetached(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<form><input id="text" value="default"><input id="check" type="checkbox"></form><div></div>`))
+	text, check := doc.GetElementById("text"), doc.GetElementById("check")
+	text.Value("typed")
+	check.Checked(true)
+
+	// the state follows the control removed and inserted again
+	text.Remove()
+	check.Remove()
+	if text.Value() != "typed" || !check.Checked() {
+		t.Errorf("\nthe state of the removed control is lost: %q %v\n", text.Value(), check.Checked())
+	}
+	div := doc.QuerySelector("div")
+	div.AppendChild(text)
+	div.AppendChild(check)
+	if text.Value() != "typed" || !check.Checked() {
+		t.Errorf("\nthe state of the inserted control is lost: %q %v\n", text.Value(), check.Checked())
+	}
+
+	// and the control moved to another document
+	other, _ := Parse(strings.NewReader(`<p></p>`))
+	text.Remove()
+	other.QuerySelector("p").AppendChild(text)
+	doc.Release()
+	if text.Value() != "typed" {
+		t.Errorf("\nthe state is released with the other document: %q\n", text.Value())
+	}
+	if check.Checked() {
+		t.Errorf("\nthe state is not released\n")
+	}
+	other.Release()
+	if text.Value() != "default" {
+		t.Errorf("\nthe state is not released: %q\n", text.Value())
+	}
+}
+
+func TestChecked(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<form>
+<input id="a" type="radio" name="r" checked>
+<input id="b" type="radio" name="r" checked>
+<input id="c" type="radio" name="r">
+<input id="x" type="checkbox" checked>
+</form>
+<input id="other" type="radio" name="r" checked>`))
+	get := func(id string) *Element { return doc.GetElementById(id) }
+
+	if get("a").Checked() || !get("b").Checked() || !get("other").Checked() {
+		t.Errorf("\nonly the last checked radio button in the group is checked\n")
+	}
+	get("c").Checked(true)
+	if get("a").Checked() || get("b").Checked() || !get("c").Checked() || !get("other").Checked() {
+		t.Errorf("\nthe radio group is wrong\n")
+	}
+	if !get("b").DefaultChecked() {
+		t.Errorf("\nthe checked attribute is changed\n")
+	}
+	get("x").Checked(false)
+	if get("x").Checked() || !get("x").DefaultChecked() {
+		t.Errorf("\nthe checkbox is wrong\n")
+	}
+}
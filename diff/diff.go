@@ -0,0 +1,427 @@
+// Package diff compares two trees of gohtml and returns the edits
+// that change the first tree into the second
+package diff
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/saihon/gohtml"
+	"github.com/saihon/gohtml/utils"
+)
+
+// Op is the kind of an edit
+type Op int
+
+const (
+	// Insert inserts the node of the new tree
+	Insert Op = iota
+	// Delete deletes the node and the descendants
+	Delete
+	// Move moves the node which is not changed
+	Move
+	// Text changes the text of a text node or a comment
+	Text
+	// AddAttribute adds an attribute
+	AddAttribute
+	// RemoveAttribute removes an attribute
+	RemoveAttribute
+	// ChangeAttribute changes the value of an attribute
+	ChangeAttribute
+	// ReorderAttributes changes the order of the attributes
+	ReorderAttributes
+)
+
+var opNames = [...]string{"insert", "delete", "move", "text", "add attribute", "remove attribute", "change attribute", "reorder attributes"}
+
+func (o Op) String() string {
+	if int(o) < len(opNames) {
+		return opNames[o]
+	}
+	return "Op(" + strconv.Itoa(int(o)) + ")"
+}
+
+// Edit is one difference between the trees
+type Edit struct {
+	Op Op
+	// Path is the path of the node in the old tree, nil for Insert.
+	// a path is the indexes of the child nodes from the root, see utils.Path
+	Path []int
+	// NewPath is the path of the node in the new tree, nil for Delete
+	NewPath []int
+	// Node is the inserted node in the new tree, or the node in the old tree
+	Node *html.Node
+	// Namespace and Key are the name of the attribute
+	Namespace, Key string
+	// Old and New are the text or the attribute values before and after
+	Old, New string
+}
+
+func formatPath(path []int) string {
+	var b strings.Builder
+	for _, i := range path {
+		b.WriteString("/" + strconv.Itoa(i))
+	}
+	if b.Len() == 0 {
+		return "/"
+	}
+	return b.String()
+}
+
+func describe(n *html.Node) string {
+	switch n.Type {
+	case html.ElementNode:
+		return "<" + n.Data + ">"
+	case html.TextNode:
+		return "#text"
+	case html.CommentNode:
+		return "#comment"
+	case html.DoctypeNode:
+		return "<!DOCTYPE " + n.Data + ">"
+	}
+	return "#document"
+}
+
+func (e Edit) String() string {
+	key := e.Key
+	if e.Namespace != "" {
+		key = e.Namespace + ":" + e.Key
+	}
+	switch e.Op {
+	case Insert:
+		return "insert " + formatPath(e.NewPath) + " " + describe(e.Node)
+	case Delete:
+		return "delete " + formatPath(e.Path) + " " + describe(e.Node)
+	case Move:
+		return "move " + formatPath(e.Path) + " -> " + formatPath(e.NewPath) + " " + describe(e.Node)
+	case Text:
+		return fmt.Sprintf("text %s %q -> %q", formatPath(e.Path), e.Old, e.New)
+	case AddAttribute:
+		return fmt.Sprintf("add attribute %s %s=%q", formatPath(e.Path), key, e.New)
+	case RemoveAttribute:
+		return fmt.Sprintf("remove attribute %s %s=%q", formatPath(e.Path), key, e.Old)
+	case ChangeAttribute:
+		return fmt.Sprintf("change attribute %s %s=%q -> %q", formatPath(e.Path), key, e.Old, e.New)
+	case ReorderAttributes:
+		return fmt.Sprintf("reorder attributes %s %s -> %s", formatPath(e.Path), e.Old, e.New)
+	}
+	return e.Op.String()
+}
+
+// Options is the options of comparing
+type Options struct {
+	// IgnoreWhitespace skips the text nodes of only white space,
+	// and compares the texts that white space is collapsed
+	IgnoreWhitespace bool
+	// IgnoreAttributeOrder compares the attributes without the order
+	IgnoreAttributeOrder bool
+	// IgnoreComments skips the comments
+	IgnoreComments bool
+}
+
+// Documents returns the edits that change the document a into b
+func Documents(a, b *gohtml.Document, opts *Options) []Edit {
+	return compare(a.Node, b.Node, opts)
+}
+
+// Elements returns the edits that change the element a into b.
+// the paths are relative to a and b
+func Elements(a, b *gohtml.Element, opts *Options) []Edit {
+	return compare(a.Node, b.Node, opts)
+}
+
+func compare(a, b *html.Node, opts *Options) []Edit {
+	d := &differ{
+		rootA:  a,
+		rootB:  b,
+		hashes: map[*html.Node]uint64{},
+		sizes:  map[*html.Node]int{},
+		memo:   map[[2]*html.Node]int{},
+	}
+	if opts != nil {
+		d.opts = *opts
+	}
+
+	if d.label(a) != d.label(b) {
+		return []Edit{
+			{Op: Delete, Path: []int{}, Node: a},
+			{Op: Insert, NewPath: []int{}, Node: b},
+		}
+	}
+	d.edit(a, b)
+	return d.moves()
+}
+
+// differ computes the top-down tree edit distance (Selkow): a node is
+// matched only if the parents are matched, the subtrees are inserted
+// or deleted as a whole, and the children are aligned by dynamic
+// programming on the costs of the matched subtrees
+type differ struct {
+	opts         Options
+	rootA, rootB *html.Node
+	hashes       map[*html.Node]uint64
+	sizes        map[*html.Node]int
+	memo         map[[2]*html.Node]int
+	edits        []Edit
+}
+
+func (d *differ) children(n *html.Node) []*html.Node {
+	var nodes []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.CommentNode && d.opts.IgnoreComments:
+		case c.Type == html.TextNode && d.opts.IgnoreWhitespace && strings.TrimSpace(c.Data) == "":
+		default:
+			nodes = append(nodes, c)
+		}
+	}
+	return nodes
+}
+
+func (d *differ) text(n *html.Node) string {
+	if d.opts.IgnoreWhitespace {
+		return strings.Join(strings.Fields(n.Data), " ")
+	}
+	return n.Data
+}
+
+// label returns the name of n. only the nodes of the same label are matched
+func (d *differ) label(n *html.Node) string {
+	switch n.Type {
+	case html.ElementNode:
+		return n.Namespace + " " + n.Data
+	case html.DoctypeNode:
+		return "#doctype " + n.Data
+	}
+	return describe(n)
+}
+
+func attrKey(a html.Attribute) string {
+	return a.Namespace + " " + a.Key
+}
+
+func (d *differ) attrs(n *html.Node) []html.Attribute {
+	attrs := n.Attr
+	if d.opts.IgnoreAttributeOrder {
+		attrs = append([]html.Attribute(nil), attrs...)
+		sort.Slice(attrs, func(i, j int) bool { return attrKey(attrs[i]) < attrKey(attrs[j]) })
+	}
+	return attrs
+}
+
+// hash returns the hash of the subtree. the same subtrees have the same hash
+func (d *differ) hash(n *html.Node) uint64 {
+	if h, ok := d.hashes[n]; ok {
+		return h
+	}
+	h := fnv.New64a()
+	h.Write([]byte(d.label(n) + "\x00"))
+	for _, a := range d.attrs(n) {
+		h.Write([]byte(attrKey(a) + "\x00" + a.Val + "\x00"))
+	}
+	if n.Type == html.TextNode || n.Type == html.CommentNode {
+		h.Write([]byte(d.text(n)))
+	}
+	for _, c := range d.children(n) {
+		fmt.Fprintf(h, "\x01%x", d.hash(c))
+	}
+	d.hashes[n] = h.Sum64()
+	return d.hashes[n]
+}
+
+// size returns the number of the nodes in the subtree
+func (d *differ) size(n *html.Node) int {
+	if s, ok := d.sizes[n]; ok {
+		return s
+	}
+	s := 1
+	for _, c := range d.children(n) {
+		s += d.size(c)
+	}
+	d.sizes[n] = s
+	return s
+}
+
+// nodeEdits returns the edits of the node itself, without the children
+func (d *differ) nodeEdits(a, b *html.Node) []Edit {
+	var edits []Edit
+	switch a.Type {
+	case html.TextNode, html.CommentNode:
+		if d.text(a) != d.text(b) {
+			edits = append(edits, Edit{Op: Text, Node: a, Old: a.Data, New: b.Data})
+		}
+		return edits
+	case html.ElementNode, html.DoctypeNode:
+	default:
+		return nil
+	}
+
+	old := map[string]html.Attribute{}
+	for _, attr := range a.Attr {
+		old[attrKey(attr)] = attr
+	}
+	seen := map[string]bool{}
+	for _, attr := range b.Attr {
+		k := attrKey(attr)
+		seen[k] = true
+		if o, ok := old[k]; !ok {
+			edits = append(edits, Edit{Op: AddAttribute, Node: a, Namespace: attr.Namespace, Key: attr.Key, New: attr.Val})
+		} else if o.Val != attr.Val {
+			edits = append(edits, Edit{Op: ChangeAttribute, Node: a, Namespace: attr.Namespace, Key: attr.Key, Old: o.Val, New: attr.Val})
+		}
+	}
+	for _, attr := range a.Attr {
+		if !seen[attrKey(attr)] {
+			edits = append(edits, Edit{Op: RemoveAttribute, Node: a, Namespace: attr.Namespace, Key: attr.Key, Old: attr.Val})
+		}
+	}
+
+	if !d.opts.IgnoreAttributeOrder {
+		// the order of the attributes which are in both
+		var before, after []string
+		for _, attr := range a.Attr {
+			if seen[attrKey(attr)] {
+				before = append(before, strings.TrimSpace(attrKey(attr)))
+			}
+		}
+		for _, attr := range b.Attr {
+			if _, ok := old[attrKey(attr)]; ok {
+				after = append(after, strings.TrimSpace(attrKey(attr)))
+			}
+		}
+		if o, n := strings.Join(before, " "), strings.Join(after, " "); o != n {
+			edits = append(edits, Edit{Op: ReorderAttributes, Node: a, Old: o, New: n})
+		}
+	}
+	return edits
+}
+
+// distance returns the cost to change the subtree a into b
+func (d *differ) distance(a, b *html.Node) int {
+	if d.hash(a) == d.hash(b) {
+		return 0
+	}
+	key := [2]*html.Node{a, b}
+	if c, ok := d.memo[key]; ok {
+		return c
+	}
+	c := len(d.nodeEdits(a, b))
+	table := d.align(d.children(a), d.children(b))
+	c += table[len(table)-1][len(table[0])-1]
+	d.memo[key] = c
+	return c
+}
+
+// substitute returns the cost to match x with y, or -1 if cannot
+func (d *differ) substitute(x, y *html.Node) int {
+	if d.label(x) != d.label(y) {
+		return -1
+	}
+	return d.distance(x, y)
+}
+
+// align returns the table of the minimum costs to change xs[:i] into ys[:j]
+func (d *differ) align(xs, ys []*html.Node) [][]int {
+	table := make([][]int, len(xs)+1)
+	for i := range table {
+		table[i] = make([]int, len(ys)+1)
+	}
+	for i := 1; i <= len(xs); i++ {
+		table[i][0] = table[i-1][0] + d.size(xs[i-1])
+	}
+	for j := 1; j <= len(ys); j++ {
+		table[0][j] = table[0][j-1] + d.size(ys[j-1])
+	}
+	for i := 1; i <= len(xs); i++ {
+		for j := 1; j <= len(ys); j++ {
+			c := table[i-1][j] + d.size(xs[i-1])
+			if v := table[i][j-1] + d.size(ys[j-1]); v < c {
+				c = v
+			}
+			if s := d.substitute(xs[i-1], ys[j-1]); s >= 0 && table[i-1][j-1]+s <= c {
+				c = table[i-1][j-1] + s
+			}
+			table[i][j] = c
+		}
+	}
+	return table
+}
+
+// edit appends the edits that change the matched a into b
+func (d *differ) edit(a, b *html.Node) {
+	for _, e := range d.nodeEdits(a, b) {
+		e.Path, e.NewPath = utils.Path(d.rootA, a), utils.Path(d.rootB, b)
+		d.edits = append(d.edits, e)
+	}
+
+	xs, ys := d.children(a), d.children(b)
+	table := d.align(xs, ys)
+
+	// backtrack from the end, and append the edits in the order of the children
+	type step struct{ x, y *html.Node }
+	var steps []step
+	i, j := len(xs), len(ys)
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && d.substitute(xs[i-1], ys[j-1]) >= 0 &&
+			table[i][j] == table[i-1][j-1]+d.substitute(xs[i-1], ys[j-1]):
+			steps = append(steps, step{xs[i-1], ys[j-1]})
+			i, j = i-1, j-1
+		case i > 0 && table[i][j] == table[i-1][j]+d.size(xs[i-1]):
+			steps = append(steps, step{xs[i-1], nil})
+			i--
+		default:
+			steps = append(steps, step{nil, ys[j-1]})
+			j--
+		}
+	}
+	for k := len(steps) - 1; k >= 0; k-- {
+		s := steps[k]
+		switch {
+		case s.y == nil:
+			d.edits = append(d.edits, Edit{Op: Delete, Path: utils.Path(d.rootA, s.x), Node: s.x})
+		case s.x == nil:
+			d.edits = append(d.edits, Edit{Op: Insert, NewPath: utils.Path(d.rootB, s.y), Node: s.y})
+		case d.hash(s.x) != d.hash(s.y):
+			d.edit(s.x, s.y)
+		}
+	}
+}
+
+// moves replaces a pair of the deleted and the inserted same subtrees with
+// a move. text nodes of only white space are not paired
+func (d *differ) moves() []Edit {
+	inserted := map[uint64][]int{}
+	for i, e := range d.edits {
+		if e.Op == Insert && (e.Node.Type != html.TextNode || strings.TrimSpace(e.Node.Data) != "") {
+			h := d.hash(e.Node)
+			inserted[h] = append(inserted[h], i)
+		}
+	}
+
+	moved := map[int]bool{}
+	for i, e := range d.edits {
+		if e.Op != Delete {
+			continue
+		}
+		h := d.hash(e.Node)
+		if list := inserted[h]; len(list) > 0 {
+			d.edits[i].Op, d.edits[i].NewPath = Move, d.edits[list[0]].NewPath
+			moved[list[0]] = true
+			inserted[h] = list[1:]
+		}
+	}
+
+	var edits []Edit
+	for i, e := range d.edits {
+		if !moved[i] {
+			edits = append(edits, e)
+		}
+	}
+	return edits
+}
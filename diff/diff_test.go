@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/saihon/gohtml"
+)
+
+func parse(s string) *gohtml.Document {
+	doc, _ := gohtml.Parse(strings.NewReader(s))
+	return doc
+}
+
+func edits(list []Edit) string {
+	var s []string
+	for _, e := range list {
+		s = append(s, e.String())
+	}
+	return strings.Join(s, "\n")
+}
+
+func TestDocuments(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		opts   *Options
+		expect string
+	}{
+		{
+			`<p>same</p>`,
+			`<p>same</p>`,
+			nil,
+			``,
+		},
+		{
+			`<p class="a" id="x">hello</p>`,
+			`<p class="b" title="t">hello world</p>`,
+			nil,
+			"change attribute /0/1/0 class=\"a\" -> \"b\"\n" +
+				"add attribute /0/1/0 title=\"t\"\n" +
+				"remove attribute /0/1/0 id=\"x\"\n" +
+				"text /0/1/0/0 \"hello\" -> \"hello world\"",
+		},
+		{
+			`<ul><li>a</li><li>b</li><li>c</li></ul>`,
+			`<ul><li>a</li><li>c</li><li>d</li></ul>`,
+			nil,
+			"text /0/1/0/1/0 \"b\" -> \"c\"\n" +
+				"text /0/1/0/2/0 \"c\" -> \"d\"",
+		},
+		{
+			`<div><p>a</p><h2>b</h2><p>c</p></div>`,
+			`<div><p>a</p><p>c</p><h3>d</h3></div>`,
+			nil,
+			"delete /0/1/0/1 <h2>\n" +
+				"insert /0/1/0/2 <h3>",
+		},
+		{
+			`<div><section><h1>Title</h1><p>long paragraph</p></section><aside>x</aside></div>`,
+			`<div><aside>x</aside><section><h1>Title</h1><p>long paragraph</p></section></div>`,
+			nil,
+			"move /0/1/0/1 -> /0/1/0/0 <aside>",
+		},
+		{
+			`<p id="a" class="b">x</p>`,
+			`<p class="b" id="a">x</p>`,
+			nil,
+			"reorder attributes /0/1/0 id class -> class id",
+		},
+		{
+			"<div>\n  <p id=\"a\" class=\"b\">one  two</p>\n  <!-- c -->\n</div>",
+			`<div><p class="b" id="a">one two</p></div>`,
+			&Options{IgnoreWhitespace: true, IgnoreAttributeOrder: true, IgnoreComments: true},
+			``,
+		},
+	}
+	for i, test := range tests {
+		actual := edits(Documents(parse(test.a), parse(test.b), test.opts))
+		if actual != test.expect {
+			t.Errorf("\n%d: got :\n%s\nwant:\n%s\n", i, actual, test.expect)
+		}
+	}
+}
+
+func TestElements(t *testing.T) {
+	a := parse(`<div><p>x</p></div>`).QuerySelector("div")
+	b := parse(`<span><p>x</p></span>`).QuerySelector("span")
+
+	expect := "delete / <div>\ninsert / <span>"
+	if actual := edits(Elements(a, b, nil)); actual != expect {
+		t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, expect)
+	}
+
+	b = parse(`<div><p>y</p><p>x</p></div>`).QuerySelector("div")
+	expect = "insert /0 <p>"
+	if actual := edits(Elements(a, b, nil)); actual != expect {
+		t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, expect)
+	}
+}
@@ -0,0 +1,69 @@
+package gohtml
+
+import (
+	"net/url"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// docData holds the state that gohtml keeps for a document beside
+// its node tree. it is looked up by the root node of the tree
+type docData struct {
+	source  *sourceMap
+	history *history
+	url     *url.URL
+}
+
+var (
+	docMu sync.Mutex
+	docs  map[*html.Node]*docData
+)
+
+// rootOf returns the top most node of the tree that n belongs to
+func rootOf(n *html.Node) *html.Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
+
+// lookupDocData returns the state of the tree that n belongs to,
+// or nil if nothing has been recorded for it
+func lookupDocData(n *html.Node) *docData {
+	docMu.Lock()
+	defer docMu.Unlock()
+	if len(docs) == 0 {
+		return nil
+	}
+	return docs[rootOf(n)]
+}
+
+// ensureDocData returns the state of the tree that n belongs to
+// and creates it if not exist yet
+func ensureDocData(n *html.Node) *docData {
+	docMu.Lock()
+	defer docMu.Unlock()
+	if docs == nil {
+		docs = make(map[*html.Node]*docData)
+	}
+	root := rootOf(n)
+	d, ok := docs[root]
+	if !ok {
+		d = &docData{}
+		docs[root] = d
+	}
+	return d
+}
+
+// Release discards all of the state kept for the document, the source
+// positions recorded by ParseWithPositions, the URL set by SetURL, the
+// undo and redo history and the values of the form controls in the
+// document. should be called when the document is no longer used
+func (d Document) Release() {
+	docMu.Lock()
+	defer docMu.Unlock()
+	root := rootOf(d.Node)
+	delete(docs, root)
+	releaseStates(root)
+}
@@ -77,7 +77,7 @@ func (d Document) Head() *Element {
 	return nil
 }
 
-// Form returns all <form> element
+// Form returns all <form> element. see Forms for "*Form"
 func (d Document) Form() Collection {
 	var m find.Matcher = func(n *html.Node) bool {
 		return utils.IsElement(n) && n.DataAtom == atom.Form
@@ -275,24 +275,37 @@ func (d Document) LastElementChild() *Element {
 // RemoveChild remove a given the "*Element"
 // specified "*Element" is must be the child of "Document"
 func (d Document) RemoveChild(c *Element) {
-	d.Node.RemoveChild(c.Node)
+	mutateChildren(d.Node, func() error {
+		d.Node.RemoveChild(c.Node)
+		return nil
+	})
 }
 
 // ReplaceChild replace oldElement to newElement
 // given "*Element" is both the must be "Document" child, and same node type
 func (d Document) ReplaceChild(newElement, oldElement *Element) *Element {
-	n := utils.Replace(d.Node, newElement.Node, oldElement.Node)
+	var n *html.Node
+	mutateChildren(d.Node, func() error {
+		n = utils.Replace(d.Node, newElement.Node, oldElement.Node)
+		return nil
+	})
 	return &Element{n}
 }
 
 // AppendChild append "*Element" as a last child
 func (d Document) AppendChild(c *Element) {
-	d.Node.AppendChild(c.Node)
+	mutateChildren(d.Node, func() error {
+		d.Node.AppendChild(c.Node)
+		return nil
+	})
 }
 
 // InsertBefore inserts a newElement before the oldElement as a child of a "Document".
 func (d Document) InsertBefore(newChild, oldChild *Element) {
-	d.Node.InsertBefore(newChild.Node, oldChild.Node)
+	mutateChildren(d.Node, func() error {
+		d.Node.InsertBefore(newChild.Node, oldChild.Node)
+		return nil
+	})
 }
 
 // CloneNode clone "Document"
@@ -305,5 +318,13 @@ func (d Document) CloneNode() *Document {
 
 // TextContent - returns nil!!
 func (d Document) TextContent(text ...string) string {
-	return utils.Text(d.Node, text...)
+	if text == nil {
+		return utils.Text(d.Node)
+	}
+	var s string
+	mutateChildren(d.Node, func() error {
+		s = utils.Text(d.Node, text...)
+		return nil
+	})
+	return s
 }
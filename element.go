@@ -96,7 +96,15 @@ func (e Element) HasChildNodes() bool {
 
 // InnerHTML set or get inner html to an element
 func (e Element) InnerHTML(text ...string) string {
-	return utils.Html(e.Node, text...)
+	if text == nil {
+		return utils.Html(e.Node)
+	}
+	var s string
+	mutateChildren(e.Node, func() error {
+		s = utils.Html(e.Node, text...)
+		return nil
+	})
+	return s
 }
 
 // OuterHTML include element itself
@@ -170,29 +178,45 @@ func (e Element) CloneNode() *Element {
 
 // Remove delete Element itself
 func (e Element) Remove() {
-	utils.Remove(e.Node)
+	mutateChildren(e.Node.Parent, func() error {
+		utils.Remove(e.Node)
+		return nil
+	})
 }
 
 // RemoveChild remove a given "*Element"
 // specified *Element is must be child
 func (e Element) RemoveChild(c *Element) {
-	e.Node.RemoveChild(c.Node)
+	mutateChildren(e.Node, func() error {
+		e.Node.RemoveChild(c.Node)
+		return nil
+	})
 }
 
 // ReplaceChild returns old element. panic if an error
 func (e Element) ReplaceChild(newElement, oldElement *Element) *Element {
-	n := utils.Replace(e.Node, newElement.Node, oldElement.Node)
+	var n *html.Node
+	mutateChildren(e.Node, func() error {
+		n = utils.Replace(e.Node, newElement.Node, oldElement.Node)
+		return nil
+	})
 	return &Element{n}
 }
 
 // AppendChild append "*Element" as last child
 func (e Element) AppendChild(c *Element) {
-	e.Node.AppendChild(c.Node)
+	mutateChildren(e.Node, func() error {
+		e.Node.AppendChild(c.Node)
+		return nil
+	})
 }
 
 // InsertBefore inserts a newChild before the oldChild as child
 func (e Element) InsertBefore(newChild, oldChild *Element) {
-	e.Node.InsertBefore(newChild.Node, oldChild.Node)
+	mutateChildren(e.Node, func() error {
+		e.Node.InsertBefore(newChild.Node, oldChild.Node)
+		return nil
+	})
 }
 
 // Position
@@ -214,18 +238,32 @@ const (
 	Afterend    = Position(utils.Afterend)
 )
 
+// mutateAdjacent runs fn that inserts nodes to position p of pivot
+// as mutateChildren of the node that gets the new children
+func mutateAdjacent(p Position, pivot *html.Node, fn func() error) error {
+	if p == Beforebegin || p == Afterend {
+		if pivot.Parent == nil {
+			return fn()
+		}
+		return mutateChildren(pivot.Parent, fn)
+	}
+	return mutateChildren(pivot, fn)
+}
+
 // InsertAdjacentHTML inserts text HTML as the html.ElementNode to specified position
 func (e Element) InsertAdjacentHTML(p Position, texthtml string) error {
 	nodes, err := html.ParseFragment(strings.NewReader(texthtml), &html.Node{Type: html.ElementNode})
 	if err != nil {
 		return err
 	}
-	for _, n := range nodes {
-		if err := utils.Insert(utils.Position(p), e.Node, n); err != nil {
-			return err
+	return mutateAdjacent(p, e.Node, func() error {
+		for _, n := range nodes {
+			if err := utils.Insert(utils.Position(p), e.Node, n); err != nil {
+				return err
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 // InsertAdjacentText inserts text as the html.TextNode to specified position
@@ -234,22 +272,41 @@ func (e Element) InsertAdjacentText(p Position, text string) error {
 		Type: html.TextNode,
 		Data: html.EscapeString(text),
 	}
-	return utils.Insert(utils.Position(p), e.Node, n)
+	return mutateAdjacent(p, e.Node, func() error {
+		return utils.Insert(utils.Position(p), e.Node, n)
+	})
 }
 
 // InsertAdjacentElement inserts element to specified position
 func (e Element) InsertAdjacentElement(p Position, newElement *Element) error {
-	return utils.Insert(utils.Position(p), e.Node, newElement.Node)
+	return mutateAdjacent(p, e.Node, func() error {
+		return utils.Insert(utils.Position(p), e.Node, newElement.Node)
+	})
 }
 
 // TextContent set or get text to an element
 func (e Element) TextContent(text ...string) string {
-	return utils.Text(e.Node, text...)
-}
-
-// InnerText set or get text to an element
-func (e Element) InnerText(text ...string) string {
-	return utils.Text(e.Node, text...)
+	if text == nil {
+		return utils.Text(e.Node)
+	}
+	var s string
+	mutateChildren(e.Node, func() error {
+		s = utils.Text(e.Node, text...)
+		return nil
+	})
+	return s
+}
+
+// NodeValue set or get the text of a text node or a comment.
+// it returns empty string for the other nodes
+func (e Element) NodeValue(value ...string) string {
+	if e.Node.Type != html.TextNode && e.Node.Type != html.CommentNode {
+		return ""
+	}
+	if value != nil {
+		mutateData(e.Node, strings.Join(value, ""))
+	}
+	return e.Node.Data
 }
 
 // TagName returns string as uppercase
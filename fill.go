@@ -0,0 +1,352 @@
+package gohtml
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/saihon/gohtml/attr"
+)
+
+var (
+	// ErrUnknownField is the error of the name that no control of the form has
+	ErrUnknownField = errors.New("gohtml: unknown field")
+	// ErrFieldValue is the error of the value that the control cannot take,
+	// like the value not among the options of <select>
+	ErrFieldValue = errors.New("gohtml: invalid field value")
+	// ErrFieldType is the error of the struct field that cannot be converted
+	ErrFieldType = errors.New("gohtml: unsupported field type")
+)
+
+// FieldError is the error of a field of Fill and FillStruct
+type FieldError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("%v %q", e.Err, e.Name)
+	}
+	return fmt.Sprintf("%v %q: %q", e.Err, e.Name, e.Value)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fillable returns the controls of the form that have the name,
+// and can be filled. buttons are never filled
+func (f Form) fillable(name string) []*html.Node {
+	var list []*html.Node
+	for _, n := range f.listed() {
+		if attr.Get(n, "name") != name {
+			continue
+		}
+		switch n.DataAtom {
+		case atom.Input:
+			if t := inputType(n); t == "submit" || t == "reset" || t == "button" || t == "image" {
+				continue
+			}
+		case atom.Select, atom.Textarea:
+		default:
+			continue
+		}
+		list = append(list, n)
+	}
+	return list
+}
+
+// Fill sets the values to the controls of the form by the names like a
+// user fills in the form. the text fields and the single selects take the
+// values of the same name in the tree order, the checkboxes, the radio
+// buttons and the options of the multiple selects are checked or selected
+// if their values are in the values and unchecked otherwise. the controls
+// not in the values are not changed. the returned error joins *FieldError
+// of the unknown names and the values that cannot be set. the state of
+// the controls is kept until Release of the document like Value, and
+// is not freed if the document is dropped without Release
+func (f Form) Fill(values url.Values) error {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		vs := values[name]
+		controls := f.fillable(name)
+		if len(controls) == 0 {
+			errs = append(errs, &FieldError{Name: name, Err: ErrUnknownField})
+			continue
+		}
+		errs = append(errs, fill(name, vs, controls)...)
+	}
+	return errors.Join(errs...)
+}
+
+// fill sets the values of the name to the controls
+func fill(name string, vs []string, controls []*html.Node) []error {
+	var errs []error
+	used := make([]bool, len(vs))
+	match := func(v string) bool {
+		found := false
+		for i := range vs {
+			if vs[i] == v {
+				used[i] = true
+				found = true
+			}
+		}
+		return found
+	}
+
+	var sequential []*html.Node
+	radio := false
+	for _, n := range controls {
+		e := Element{n}
+		switch {
+		case n.DataAtom == atom.Input && inputType(n) == "checkbox":
+			e.Checked(match(e.Value()))
+		case n.DataAtom == atom.Input && inputType(n) == "radio":
+			if !radio {
+				// uncheck the group, then check the matched one
+				for _, r := range radioGroup(n) {
+					s := ensureState(r)
+					s.checked, s.dirtyChecked = false, true
+				}
+				radio = true
+			}
+			if match(e.Value()) {
+				e.Checked(true)
+			}
+		case n.DataAtom == atom.Select && attr.Has(n, "multiple"):
+			for _, o := range options(n) {
+				s := ensureState(o)
+				s.selected, s.dirtySelected = match(optionValue(o)), true
+			}
+		case n.DataAtom == atom.Input && inputType(n) == "file":
+			errs = append(errs, &FieldError{Name: name, Err: fmt.Errorf("%w: cannot fill the file input", ErrFieldValue)})
+		default:
+			sequential = append(sequential, n)
+		}
+	}
+
+	i := 0
+	for _, n := range sequential {
+		for i < len(vs) && used[i] {
+			i++
+		}
+		if i == len(vs) {
+			break
+		}
+		v := vs[i]
+		used[i] = true
+
+		if n.DataAtom == atom.Select {
+			if !hasOption(n, v) {
+				errs = append(errs, &FieldError{Name: name, Value: v, Err: ErrFieldValue})
+				continue
+			}
+			selectValue(n, v)
+			continue
+		}
+		if sanitizeValue(n, v) != v {
+			errs = append(errs, &FieldError{Name: name, Value: v, Err: ErrFieldValue})
+			continue
+		}
+		Element{n}.Value(v)
+	}
+
+	for i, v := range vs {
+		if !used[i] {
+			errs = append(errs, &FieldError{Name: name, Value: v, Err: ErrFieldValue})
+		}
+	}
+	return errs
+}
+
+// hasOption returns true if <select> has the option of the value
+func hasOption(sel *html.Node, v string) bool {
+	for _, o := range options(sel) {
+		if optionValue(o) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// FillStruct fills the form by the exported fields of the struct v like
+// Fill. the name of the field is given by the "form" tag, or the field
+// name. the tag "-" skips the field and the embedded structs are
+// flattened. the string, bool, number, time.Time, encoding.TextMarshaler
+// and the slice of them are supported. a bool field checks or unchecks
+// the checkboxes, and time.Time is formatted by the type of the input.
+// the state of the controls needs Release like Fill
+func (f Form) FillStruct(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T is not a struct", ErrFieldType, v)
+	}
+
+	values := url.Values{}
+	errs := f.structValues(rv, values)
+	return errors.Join(append(errs, f.Fill(values))...)
+}
+
+func (f Form) structValues(rv reflect.Value, values url.Values) []error {
+	var errs []error
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("form")
+		if tag == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if field.Anonymous && tag == "" {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				errs = append(errs, f.structValues(fv, values)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		vs, ok, err := f.fieldValues(name, fv)
+		if err != nil {
+			errs = append(errs, &FieldError{Name: name, Err: err})
+			continue
+		}
+		if ok {
+			values[name] = vs
+		}
+	}
+	return errs
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// fieldValues returns the values of the struct field, ok is false for nil
+func (f Form) fieldValues(name string, fv reflect.Value) ([]string, bool, error) {
+	for fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return nil, false, nil
+		}
+		fv = fv.Elem()
+	}
+
+	controls := f.fillable(name)
+	if fv.Kind() == reflect.Bool && len(controls) > 0 &&
+		controls[0].DataAtom == atom.Input && inputType(controls[0]) == "checkbox" {
+		var vs []string
+		if fv.Bool() {
+			for _, n := range controls {
+				vs = append(vs, Element{n}.Value())
+			}
+		}
+		return vs, true, nil
+	}
+
+	typ := "text"
+	if len(controls) > 0 && controls[0].DataAtom == atom.Input {
+		typ = inputType(controls[0])
+	}
+	if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && fv.Type().Elem().Kind() != reflect.Uint8 {
+		vs := []string{}
+		for i := 0; i < fv.Len(); i++ {
+			s, err := formatValue(typ, fv.Index(i))
+			if err != nil {
+				return nil, false, err
+			}
+			vs = append(vs, s)
+		}
+		return vs, true, nil
+	}
+	s, err := formatValue(typ, fv)
+	if err != nil {
+		return nil, false, err
+	}
+	return []string{s}, true, nil
+}
+
+// formatValue returns the string of the value for the input type
+func formatValue(typ string, v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		return formatTime(typ, v.Interface().(time.Time)), nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrFieldType, v.Type())
+}
+
+// formatTime formats the time as the value of the input type
+func formatTime(typ string, t time.Time) string {
+	clock := "15:04"
+	if t.Second() != 0 || t.Nanosecond() != 0 {
+		clock = "15:04:05.999"
+	}
+	switch typ {
+	case "date":
+		return t.Format("2006-01-02")
+	case "month":
+		return t.Format("2006-01")
+	case "week":
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	case "time":
+		return t.Format(clock)
+	case "datetime-local":
+		return t.Format("2006-01-02T" + clock)
+	}
+	return t.Format(time.RFC3339)
+}
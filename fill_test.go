@@ -0,0 +1,127 @@
+package gohtml
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const test_fill = `<form>
+<input name="user">
+<input name="age" type="number">
+<input name="tag" type="checkbox" value="go">
+<input name="tag" type="checkbox" value="html" checked>
+<input name="color" type="radio" value="red" checked>
+<input name="color" type="radio" value="blue">
+<select name="lang"><option>en</option><option>ja</option></select>
+<select name="multi" multiple><option>1</option><option>2</option><option>3</option></select>
+<textarea name="note"></textarea>
+<input name="day" type="date">
+<input name="phone"><input name="phone">
+<input name="agree" type="checkbox">
+</form>`
+
+func TestFill(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(test_fill))
+	form := doc.Form().Get(0).Form()
+
+	err := form.Fill(url.Values{
+		"user":  {"alice"},
+		"age":   {"30"},
+		"tag":   {"go"},
+		"color": {"blue"},
+		"lang":  {"ja"},
+		"multi": {"1", "3"},
+		"note":  {"hi\nthere"},
+		"phone": {"1", "2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := form.FormData(nil)
+	expect := "user=alice&age=30&tag=go&color=blue&lang=ja&multi=1&multi=3&note=hi%0D%0Athere&day=&phone=1&phone=2"
+	if actual := data.URLEncoded(); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+	if form.QuerySelector("[name=user]").HasAttribute("value") {
+		t.Errorf("\nthe value attribute is set\n")
+	}
+
+	err = form.Fill(url.Values{
+		"nope":  {"x"},
+		"age":   {"thirty"},
+		"lang":  {"fr"},
+		"color": {"green"},
+		"user":  {"a", "b"},
+	})
+	var list []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		list = append(list, e.Error())
+	}
+	expectErrors := []string{
+		`gohtml: invalid field value "age": "thirty"`,
+		`gohtml: invalid field value "color": "green"`,
+		`gohtml: invalid field value "lang": "fr"`,
+		`gohtml: unknown field "nope"`,
+		`gohtml: invalid field value "user": "b"`,
+	}
+	if actual, expect := strings.Join(list, "\n"), strings.Join(expectErrors, "\n"); actual != expect {
+		t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, expect)
+	}
+	if !errors.Is(err, ErrUnknownField) || !errors.Is(err, ErrFieldValue) {
+		t.Errorf("\nerrors.Is is false\n")
+	}
+}
+
+type fillBase struct {
+	User string `form:"user"`
+}
+
+type fillForm struct {
+	fillBase
+	Age    int       `form:"age"`
+	Tags   []string  `form:"tag"`
+	Lang   string    `form:"lang"`
+	Multi  []int     `form:"multi"`
+	Day    time.Time `form:"day"`
+	Agree  bool      `form:"agree"`
+	Note   *string   `form:"note"`
+	Ignore string    `form:"-"`
+	hidden string
+}
+
+func TestFillStruct(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(test_fill))
+	form := doc.Form().Get(0).Form()
+
+	v := fillForm{
+		fillBase: fillBase{User: "bob"},
+		Age:      7,
+		Tags:     []string{"go", "html"},
+		Lang:     "en",
+		Multi:    []int{2},
+		Day:      time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		Agree:    true,
+	}
+	if err := form.FillStruct(&v); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := form.FormData(nil)
+	expect := "user=bob&age=7&tag=go&tag=html&color=red&lang=en&multi=2&note=&day=2024-02-29&phone=&phone=&agree=on"
+	if actual := data.URLEncoded(); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+
+	err := form.FillStruct(struct {
+		Unknown string
+		Lang    map[string]int `form:"lang"`
+	}{})
+	if !errors.Is(err, ErrFieldType) || !errors.Is(err, ErrUnknownField) {
+		t.Errorf("\ngot : %v\n", err)
+	}
+	if err := form.FillStruct(1); !errors.Is(err, ErrFieldType) {
+		t.Errorf("\ngot : %v\n", err)
+	}
+}
@@ -0,0 +1,638 @@
+package gohtml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/saihon/gohtml/attr"
+	"github.com/saihon/gohtml/find"
+	"github.com/saihon/gohtml/utils"
+)
+
+// Form is the <form> element
+type Form struct {
+	Element
+}
+
+// Form returns the element as "*Form" if it is <form>, or the form
+// owner if it is a form-associated element like <input>. otherwise nil
+func (e Element) Form() *Form {
+	if e.Node == nil || e.Node.Type != html.ElementNode {
+		return nil
+	}
+	if e.Node.DataAtom == atom.Form {
+		return &Form{e}
+	}
+	if listedElements[e.Node.DataAtom] {
+		if n := formOwner(e.Node); n != nil {
+			return &Form{Element{n}}
+		}
+	}
+	return nil
+}
+
+// Forms returns all <form> elements of the document as "*Form"
+func (d Document) Forms() []*Form {
+	nodes := find.All(d.Node, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.DataAtom == atom.Form
+	})
+	forms := make([]*Form, len(nodes))
+	for i, n := range nodes {
+		forms[i] = &Form{Element{n}}
+	}
+	return forms
+}
+
+// NamedForm returns the first <form> that has the id or the name
+// like document.forms[name] of JavaScript, or nil
+func (d Document) NamedForm(name string) *Form {
+	n := find.First(d.Node, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.DataAtom == atom.Form &&
+			(attr.Get(n, "id") == name || attr.Get(n, "name") == name)
+	})
+	if n == nil {
+		return nil
+	}
+	return &Form{Element{n}}
+}
+
+// FormEntry is an entry of the form data set
+type FormEntry struct {
+	Name  string
+	Value string
+	// File is true for the entry of <input type=file>, Value is the
+	// content and Filename is the name of the file. the entry of the
+	// input without any file has empty Filename and Value
+	File     bool
+	Filename string
+}
+
+// FormData is the form data set in the tree order of the controls
+type FormData []FormEntry
+
+// listedElements is the form-associated elements that belong to the form
+var listedElements = map[atom.Atom]bool{
+	atom.Button:   true,
+	atom.Fieldset: true,
+	atom.Input:    true,
+	atom.Object:   true,
+	atom.Output:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+}
+
+// inputTypes is the known values of the type attribute of <input>
+var inputTypes = map[string]bool{
+	"hidden": true, "text": true, "search": true, "tel": true, "url": true,
+	"email": true, "password": true, "date": true, "month": true, "week": true,
+	"time": true, "datetime-local": true, "number": true, "range": true,
+	"color": true, "checkbox": true, "radio": true, "file": true,
+	"submit": true, "image": true, "reset": true, "button": true,
+}
+
+// inputType returns the state of the type attribute of <input>
+func inputType(n *html.Node) string {
+	t := strings.ToLower(strings.TrimSpace(attr.Get(n, "type")))
+	if !inputTypes[t] {
+		return "text"
+	}
+	return t
+}
+
+// formOwner returns the form that the listed element belongs to
+func formOwner(n *html.Node) *html.Node {
+	if attr.Has(n, "form") {
+		f := find.ById(rootOf(n), attr.Get(n, "form"))
+		if f != nil && f.DataAtom == atom.Form {
+			return f
+		}
+		return nil
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.DataAtom == atom.Form {
+			return p
+		}
+	}
+	return nil
+}
+
+// listed returns the listed elements of the form in the tree order
+func (f Form) listed() []*html.Node {
+	return find.All(rootOf(f.Node), func(n *html.Node) bool {
+		return n.Type == html.ElementNode && listedElements[n.DataAtom] &&
+			n.Namespace == "" && formOwner(n) == f.Node
+	})
+}
+
+// Elements returns the controls of the form in the tree order,
+// like form.elements of JavaScript. <input type=image> is not included
+func (f Form) Elements() Collection {
+	var c Collection
+	for _, n := range f.listed() {
+		if n.DataAtom == atom.Input && inputType(n) == "image" {
+			continue
+		}
+		c.Nodes = append(c.Nodes, n)
+	}
+	return c
+}
+
+// disabledControl returns true if the control is disabled
+// by itself or by the ancestor <fieldset disabled>
+func disabledControl(n *html.Node) bool {
+	if attr.Has(n, "disabled") {
+		return true
+	}
+	for c, p := n, n.Parent; p != nil; c, p = p, p.Parent {
+		if p.Type != html.ElementNode || p.DataAtom != atom.Fieldset || !attr.Has(p, "disabled") {
+			continue
+		}
+		// the contents of the first legend are not disabled
+		if c.DataAtom == atom.Legend && c == firstLegend(p) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func firstLegend(fieldset *html.Node) *html.Node {
+	for c := fieldset.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Legend {
+			return c
+		}
+	}
+	return nil
+}
+
+// submitButton returns true if the element is a submit button
+func submitButton(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Button:
+		t := strings.ToLower(strings.TrimSpace(attr.Get(n, "type")))
+		return t != "reset" && t != "button"
+	case atom.Input:
+		t := inputType(n)
+		return t == "submit" || t == "image"
+	}
+	return false
+}
+
+// inDatalist returns true if the element is in <datalist>
+func inDatalist(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.DataAtom == atom.Datalist {
+			return true
+		}
+	}
+	return false
+}
+
+// checkedness returns true if the checkbox or the radio button is
+// checked. only the last checked radio button in a group is checked
+// by the attributes, and the one checked by Checked takes precedence
+func checkedness(n *html.Node) bool {
+	if s := stateOf(n); s != nil && s.dirtyChecked {
+		return s.checked
+	}
+	if !attr.Has(n, "checked") {
+		return false
+	}
+	if inputType(n) != "radio" || attr.Get(n, "name") == "" {
+		return true
+	}
+	owner, name := formOwner(n), attr.Get(n, "name")
+	later := find.First(rootOf(n), func(c *html.Node) bool {
+		if c == n || c.Type != html.ElementNode || c.DataAtom != atom.Input ||
+			inputType(c) != "radio" || attr.Get(c, "name") != name || formOwner(c) != owner {
+			return false
+		}
+		if s := stateOf(c); s != nil && s.dirtyChecked {
+			return s.checked
+		}
+		return attr.Has(c, "checked") && precedes(n, c)
+	})
+	return later == nil
+}
+
+// precedes returns true if a is before b in the tree order
+func precedes(a, b *html.Node) bool {
+	root := rootOf(a)
+	pa, pb := utils.Path(root, a), utils.Path(root, b)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] != pb[i] {
+			return pa[i] < pb[i]
+		}
+	}
+	return len(pa) < len(pb)
+}
+
+// options returns the list of options of <select>
+func options(sel *html.Node) []*html.Node {
+	var list []*html.Node
+	for c := sel.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.DataAtom {
+		case atom.Option:
+			list = append(list, c)
+		case atom.Optgroup:
+			for o := c.FirstChild; o != nil; o = o.NextSibling {
+				if o.Type == html.ElementNode && o.DataAtom == atom.Option {
+					list = append(list, o)
+				}
+			}
+		}
+	}
+	return list
+}
+
+// disabledOption returns true if the option or its optgroup is disabled
+func disabledOption(n *html.Node) bool {
+	if attr.Has(n, "disabled") {
+		return true
+	}
+	p := n.Parent
+	return p != nil && p.DataAtom == atom.Optgroup && attr.Has(p, "disabled")
+}
+
+// optionValue returns the value of <option>
+func optionValue(n *html.Node) string {
+	if a, ok := attr.GetNode(n, "value"); ok {
+		return a.Val
+	}
+	return optionLabel(n)
+}
+
+// optionLabel returns the text of <option> that white space is collapsed
+func optionLabel(n *html.Node) string {
+	return strings.TrimSpace(collapseSpace(utils.Text(n)))
+}
+
+// displaySize returns the number of rows of <select>
+func displaySize(sel *html.Node) int {
+	var size int
+	if _, err := fmt.Sscan(attr.Get(sel, "size"), &size); err == nil && size > 0 {
+		return size
+	}
+	if attr.Has(sel, "multiple") {
+		return 4
+	}
+	return 1
+}
+
+// selectedOptions returns the selected options of <select> by the
+// selectedness setting algorithm. a drop-down box without a selected
+// option selects its first option that is not disabled, unless the
+// selectedness has been set by Selected or Value, and the last one
+// is selected if more than one are selected
+func selectedOptions(sel *html.Node) []*html.Node {
+	list := options(sel)
+	var selected []*html.Node
+	dirty := false
+	for _, o := range list {
+		if optionSelected(o) {
+			selected = append(selected, o)
+		}
+		if s := stateOf(o); s != nil && s.dirtySelected {
+			dirty = true
+		}
+	}
+	if attr.Has(sel, "multiple") {
+		return selected
+	}
+	if len(selected) > 1 {
+		return selected[len(selected)-1:]
+	}
+	if len(selected) == 0 && !dirty && displaySize(sel) == 1 {
+		for _, o := range list {
+			if !disabledOption(o) {
+				return []*html.Node{o}
+			}
+		}
+	}
+	return selected
+}
+
+// FormData returns the form data set of the form submitted by the
+// submitter. submitter can be nil, or must be a submit button of the form
+func (f Form) FormData(submitter *Element) (FormData, error) {
+	var sub *html.Node
+	if submitter != nil {
+		sub = submitter.Node
+		if !submitButton(sub) || formOwner(sub) != f.Node {
+			return nil, errors.New("gohtml: submitter is not a submit button of the form")
+		}
+	}
+
+	var data FormData
+	for _, n := range f.listed() {
+		if inDatalist(n) || disabledControl(n) {
+			continue
+		}
+		if (n.DataAtom == atom.Button || n.DataAtom == atom.Input && submitButton(n)) && n != sub {
+			continue
+		}
+		name := attr.Get(n, "name")
+
+		switch n.DataAtom {
+		case atom.Input:
+			switch t := inputType(n); t {
+			case "image":
+				if name != "" {
+					name += "."
+				}
+				data = append(data, FormEntry{Name: name + "x", Value: "0"}, FormEntry{Name: name + "y", Value: "0"})
+				continue
+			case "checkbox", "radio":
+				if name == "" || !checkedness(n) {
+					continue
+				}
+				v, ok := attr.GetNode(n, "value")
+				if !ok {
+					v.Val = "on"
+				}
+				data = append(data, FormEntry{Name: name, Value: v.Val})
+			case "file":
+				if name != "" {
+					data = append(data, FormEntry{Name: name, File: true})
+				}
+				continue
+			case "reset", "button":
+				// the buttons other than the submitter are not submitted
+				continue
+			case "submit":
+				if name != "" {
+					data = append(data, FormEntry{Name: name, Value: attr.Get(n, "value")})
+				}
+				continue
+			default:
+				if name == "" {
+					continue
+				}
+				v := controlValue(n)
+				if t == "hidden" && strings.EqualFold(name, "_charset_") && !attr.Has(n, "value") {
+					v = "UTF-8"
+				}
+				data = append(data, FormEntry{Name: name, Value: v})
+			}
+		case atom.Select:
+			if name == "" {
+				continue
+			}
+			for _, o := range selectedOptions(n) {
+				if !disabledOption(o) {
+					data = append(data, FormEntry{Name: name, Value: optionValue(o)})
+				}
+			}
+			continue
+		case atom.Textarea:
+			if name == "" {
+				continue
+			}
+			data = append(data, FormEntry{Name: name, Value: controlValue(n)})
+		case atom.Button:
+			if name != "" {
+				data = append(data, FormEntry{Name: name, Value: attr.Get(n, "value")})
+			}
+			continue
+		default:
+			// <fieldset>, <output> and <object> are not submitted
+			continue
+		}
+
+		// the direction of the text field
+		if d := attr.Get(n, "dirname"); d != "" && (n.DataAtom == atom.Textarea ||
+			inputType(n) == "text" || inputType(n) == "search") {
+			dir := strings.ToLower(attr.Get(n, "dir"))
+			if dir != "rtl" {
+				dir = "ltr"
+			}
+			data = append(data, FormEntry{Name: d, Value: dir})
+		}
+	}
+	return data, nil
+}
+
+// normalizeNewlines converts every line break to CRLF
+func normalizeNewlines(s string) string {
+	if !strings.ContainsAny(s, "\r\n") {
+		return s
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+// value returns the value of the entry as the name-value pair.
+// the file is replaced by the filename
+func (e FormEntry) value() string {
+	if e.File {
+		return e.Filename
+	}
+	return e.Value
+}
+
+// Values returns the form data set as url.Values
+func (d FormData) Values() url.Values {
+	v := url.Values{}
+	for _, e := range d {
+		v.Add(normalizeNewlines(e.Name), normalizeNewlines(e.value()))
+	}
+	return v
+}
+
+// URLEncoded returns the form data set encoded as
+// application/x-www-form-urlencoded in the order of the entries
+func (d FormData) URLEncoded() string {
+	var b strings.Builder
+	for i, e := range d {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(url.QueryEscape(normalizeNewlines(e.Name)))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(normalizeNewlines(e.value())))
+	}
+	return b.String()
+}
+
+// TextPlain returns the form data set encoded as text/plain
+func (d FormData) TextPlain() string {
+	var b strings.Builder
+	for _, e := range d {
+		b.WriteString(normalizeNewlines(e.Name))
+		b.WriteByte('=')
+		b.WriteString(normalizeNewlines(e.value()))
+		b.WriteString("\r\n")
+	}
+	return b.String()
+}
+
+// multipartEscaper escapes the name and the filename in the header
+var multipartEscaper = strings.NewReplacer("\r", "%0D", "\n", "%0A", `"`, "%22")
+
+// Multipart writes the form data set encoded as multipart/form-data
+// to w, and returns the content type including the boundary
+func (d FormData) Multipart(w io.Writer) (string, error) {
+	mw := multipart.NewWriter(w)
+	for _, e := range d {
+		h := textproto.MIMEHeader{}
+		disposition := `form-data; name="` + multipartEscaper.Replace(normalizeNewlines(e.Name)) + `"`
+		value := normalizeNewlines(e.Value)
+		if e.File {
+			disposition += `; filename="` + multipartEscaper.Replace(e.Filename) + `"`
+			h.Set("Content-Type", "application/octet-stream")
+			value = e.Value
+		}
+		h.Set("Content-Disposition", disposition)
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			return "", err
+		}
+		if _, err := io.WriteString(part, value); err != nil {
+			return "", err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+	return mw.FormDataContentType(), nil
+}
+
+// submitAttr returns the attribute of the form that can be overridden
+// by the attribute with "form" prefix of the submitter, like formaction
+func (f Form) submitAttr(submitter *html.Node, key string) (string, bool) {
+	if submitter != nil {
+		if a, ok := attr.GetNode(submitter, "form"+key); ok {
+			return a.Val, true
+		}
+	}
+	a, ok := attr.GetNode(f.Node, key)
+	return a.Val, ok
+}
+
+// Method returns the method the form is submitted with by the submitter,
+// which is "get", "post" or "dialog". submitter can be nil
+func (f Form) Method(submitter *Element) string {
+	v, _ := f.submitAttr(elementNode(submitter), "method")
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case "post", "dialog":
+		return v
+	}
+	return "get"
+}
+
+// Enctype returns the encoding type the form is submitted with by the submitter
+func (f Form) Enctype(submitter *Element) string {
+	v, _ := f.submitAttr(elementNode(submitter), "enctype")
+	switch v = strings.ToLower(strings.TrimSpace(v)); v {
+	case "multipart/form-data", "text/plain":
+		return v
+	}
+	return "application/x-www-form-urlencoded"
+}
+
+// Action returns the URL the form is submitted to by the submitter,
+// resolved against base, or the base URL of the document if base is nil.
+// base is used if the action is empty
+func (f Form) Action(submitter *Element, base *url.URL) (*url.URL, error) {
+	v, _ := f.submitAttr(elementNode(submitter), "action")
+	v = strings.TrimSpace(v)
+	if base == nil {
+		base = baseURI(f.Node)
+	}
+	if base == nil {
+		base = &url.URL{}
+	}
+	if v == "" {
+		u := *base
+		return &u, nil
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return nil, err
+	}
+	return base.ResolveReference(u), nil
+}
+
+func elementNode(e *Element) *html.Node {
+	if e == nil {
+		return nil
+	}
+	return e.Node
+}
+
+// Submit returns the request that a browser sends when the form is
+// submitted by the submitter. submitter can be nil like form.submit()
+// of JavaScript. the action is resolved against base, or the base URL
+// of the document if base is nil. the form data
+// replaces the query of the action for the method "get", or is the
+// body encoded by the enctype for "post". for the method "dialog",
+// the <dialog> that the form is in is closed and the request is nil
+func (f Form) Submit(submitter *Element, base *url.URL) (*http.Request, error) {
+	data, err := f.FormData(submitter)
+	if err != nil {
+		return nil, err
+	}
+
+	method := f.Method(submitter)
+	if method == "dialog" {
+		for p := f.Node.Parent; p != nil; p = p.Parent {
+			if p.Type == html.ElementNode && p.DataAtom == atom.Dialog {
+				if attr.Has(p, "open") {
+					(&Element{p}).RemoveAttribute("open")
+				}
+				break
+			}
+		}
+		return nil, nil
+	}
+
+	action, err := f.Action(submitter, base)
+	if err != nil {
+		return nil, err
+	}
+	if action.Scheme != "http" && action.Scheme != "https" {
+		return nil, fmt.Errorf("gohtml: cannot submit the form to %q", action.String())
+	}
+
+	if method == "get" {
+		action.RawQuery = data.URLEncoded()
+		action.ForceQuery = false
+		return http.NewRequest(http.MethodGet, action.String(), nil)
+	}
+
+	var (
+		body        io.Reader
+		contentType = f.Enctype(submitter)
+	)
+	switch contentType {
+	case "multipart/form-data":
+		var b strings.Builder
+		if contentType, err = data.Multipart(&b); err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(b.String())
+	case "text/plain":
+		body = strings.NewReader(data.TextPlain())
+	default:
+		body = strings.NewReader(data.URLEncoded())
+	}
+	req, err := http.NewRequest(http.MethodPost, action.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return req, nil
+}
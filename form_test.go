@@ -0,0 +1,232 @@
+package gohtml
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const test_form = `<form id="f">
+<input name="q" value="go  html">
+<input name="nameless-skipped" disabled value="x">
+<input type="checkbox" name="c" checked>
+<input type="checkbox" name="c" value="two">
+<input type="radio" name="r" value="a" checked>
+<input type="radio" name="r" value="b" checked>
+<input type="hidden" name="_charset_">
+<input type="file" name="upload">
+<select name="s"><option>  first  one </option><option value="2">second</option></select>
+<select name="m" multiple><optgroup disabled><option selected>x</option></optgroup><option selected value="y">Y</option><option selected disabled>z</option></select>
+<textarea name="t" dirname="t.dir">line1
+line2</textarea>
+<fieldset disabled><input name="off" value="1"><legend><input name="on" value="1"></legend></fieldset>
+<fieldset disabled><legend>l</legend><legend><input name="off2" value="1"></legend></fieldset>
+<datalist><input name="dl" value="1"></datalist>
+<button name="b1" value="1">one</button>
+<button name="b2" value="2" type="button">two</button>
+<input type="image" name="img">
+</form>
+<input name="outside" form="f" value="o">
+<input name="other" form="nope" value="x">`
+
+func TestFormData(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(test_form))
+	form := doc.Form().Get(0).Form()
+	if form == nil {
+		t.Fatal("\nno form\n")
+	}
+
+	data, err := form.FormData(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "q=go++html&c=on&r=b&_charset_=UTF-8&upload=&s=first+one&m=y&t=line1%0D%0Aline2&t.dir=ltr&on=1&outside=o"
+	if actual := data.URLEncoded(); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+
+	data, err = form.FormData(doc.QuerySelector("button"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := data.Values().Get("b1"); actual != "1" {
+		t.Errorf("\ngot : %q, want: %q\n", actual, "1")
+	}
+
+	data, _ = form.FormData(doc.QuerySelector("[type=image]"))
+	if v := data.Values(); v.Get("img.x") != "0" || v.Get("img.y") != "0" {
+		t.Errorf("\ngot : %v\n", v)
+	}
+
+	if _, err := form.FormData(doc.QuerySelector("[type=button]")); err == nil {
+		t.Errorf("\nexpected an error\n")
+	}
+	if doc.QuerySelector("[name=outside]").Form().Node != form.Node {
+		t.Errorf("\nthe form owner of the form attribute is wrong\n")
+	}
+	if doc.QuerySelector("[name=other]").Form() != nil || doc.Body().Form() != nil {
+		t.Errorf("\nexpected nil\n")
+	}
+	if n := form.Elements().Length(); n != 20 {
+		t.Errorf("\ngot : %d elements, want: 20\n", n)
+	}
+}
+
+func TestFormDataButtons(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<form><input name="a" value="1"><input type="reset" name="r" value="R"><input type="button" name="n" value="N"><input type="submit" name="s" value="S"><input name="b" value="B"></form>`))
+	form := doc.Form().Get(0).Form()
+
+	tests := []struct {
+		submitter *Element
+		expect    string
+	}{
+		{nil, "a=1&b=B"},
+		{doc.QuerySelector("[type=submit]"), "a=1&s=S&b=B"},
+	}
+	for _, tt := range tests {
+		data, err := form.FormData(tt.submitter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := data.URLEncoded(); actual != tt.expect {
+			t.Errorf("\ngot : %s\nwant: %s\n", actual, tt.expect)
+		}
+	}
+}
+
+func TestForms(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<form id="a"></form><div><form name="b"></form></div>`))
+	forms := doc.Forms()
+	if len(forms) != 2 || forms[0].Node != doc.Form().Get(0).Node || forms[1].Node != doc.Form().Get(1).Node {
+		t.Fatalf("\ngot : %d forms, want: 2\n", len(forms))
+	}
+	if f := doc.NamedForm("a"); f == nil || f.Node != forms[0].Node {
+		t.Errorf("\nthe form of the id is not found\n")
+	}
+	if f := doc.NamedForm("b"); f == nil || f.Node != forms[1].Node {
+		t.Errorf("\nthe form of the name is not found\n")
+	}
+	if doc.NamedForm("c") != nil {
+		t.Errorf("\nthe unknown form must be nil\n")
+	}
+}
+
+func TestFormDataEncoding(t *testing.T) {
+	data := FormData{
+		{Name: "a b", Value: "x\ny"},
+		{Name: `q"`, Value: "1"},
+		{Name: "f", File: true, Filename: "a.txt", Value: "content"},
+	}
+
+	expect := "a b=x\r\ny\r\nq\"=1\r\nf=a.txt\r\n"
+	if actual := data.TextPlain(); actual != expect {
+		t.Errorf("\ngot : %q\nwant: %q\n", actual, expect)
+	}
+
+	var b strings.Builder
+	contentType, err := data.Multipart(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, _ := mime.ParseMediaType(contentType)
+	r := multipart.NewReader(strings.NewReader(b.String()), params["boundary"])
+	var parts []string
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(p)
+		parts = append(parts, p.Header.Get("Content-Disposition")+" "+p.Header.Get("Content-Type")+" "+string(body))
+	}
+	expectParts := []string{
+		`form-data; name="a b"  x` + "\r\ny",
+		`form-data; name="q%22"  1`,
+		`form-data; name="f"; filename="a.txt" application/octet-stream content`,
+	}
+	if len(parts) != len(expectParts) {
+		t.Fatalf("\ngot : %q\n", parts)
+	}
+	for i := range parts {
+		if parts[i] != expectParts[i] {
+			t.Errorf("\n%d: got : %q\nwant: %q\n", i, parts[i], expectParts[i])
+		}
+	}
+}
+
+func TestFormSubmit(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`
+<form action="/search?old=1#top">
+	<input name="q" value="a b">
+	<button id="get">go</button>
+	<button id="post" formaction="post" formmethod="POST" formenctype="text/plain">post</button>
+	<button id="multi" formmethod="post" formenctype="multipart/form-data">multi</button>
+	<button id="bad" formmethod="put" formenctype="x">bad</button>
+</form>
+<dialog open><form method="dialog"><button>close</button></form></dialog>`))
+	form := doc.Form().Get(0).Form()
+
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		got = append(got, r.Method+" "+r.URL.Path+" "+r.Header.Get("Content-Type")+" "+r.FormValue("q"))
+	}))
+	defer server.Close()
+	base, _ := url.Parse(server.URL + "/page/index.html")
+
+	tests := []struct {
+		id, url string
+	}{
+		{"get", server.URL + "/search?q=a+b#top"},
+		{"post", server.URL + "/page/post"},
+		{"multi", server.URL + "/search?old=1#top"},
+		{"bad", server.URL + "/search?q=a+b#top"},
+	}
+	for i, test := range tests {
+		req, err := form.Submit(doc.GetElementById(test.id), base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := req.URL.String(); actual != test.url {
+			t.Errorf("\n%d: got : %s, want: %s\n", i, actual, test.url)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	expect := []string{
+		"GET /search  a b",
+		"POST /page/post text/plain ",
+		"POST /search multipart/form-data; boundary=",
+		"GET /search  a b",
+	}
+	for i := range expect {
+		if i >= len(got) || !strings.HasPrefix(got[i], expect[i]) {
+			t.Errorf("\n%d: got : %q\n", i, got)
+		}
+	}
+	if !strings.HasSuffix(got[2], " a b") {
+		t.Errorf("\nthe multipart body is wrong: %q\n", got[2])
+	}
+
+	if _, err := form.Submit(nil, nil); err == nil {
+		t.Errorf("\nexpected an error for the relative action without base\n")
+	}
+
+	dialog := doc.QuerySelector("dialog")
+	req, err := doc.Form().Get(1).Form().Submit(nil, base)
+	if req != nil || err != nil || dialog.HasAttribute("open") {
+		t.Errorf("\nthe dialog is not closed: %v %v\n", req, err)
+	}
+}
@@ -0,0 +1,284 @@
+package gohtml
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// AttributeWrap decides when the attributes of a start tag
+// are written on each own line by Format
+type AttributeWrap int
+
+const (
+	// WrapAuto wraps attributes if the start tag exceeds the LineWidth
+	WrapAuto AttributeWrap = iota
+	// WrapNever never wraps attributes
+	WrapNever
+	// WrapAlways wraps attributes if the element has more than one attribute
+	WrapAlways
+)
+
+// FormatOptions is the options for Format
+type FormatOptions struct {
+	// Indent is the string of one level indentation. two spaces if empty
+	Indent string
+	// LineWidth is the maximum width of a line. 0 means unlimited.
+	// a line is broken only where there is a white space already
+	LineWidth int
+	// AttributeWrap decides when attributes are wrapped
+	AttributeWrap AttributeWrap
+}
+
+// Format writes the pretty-printed HTML of the element to w.
+// the contents of pre, textarea, script and style are written as is,
+// and white space in the inline contents is collapsed to single space
+func (e Element) Format(w io.Writer, opts *FormatOptions) error {
+	return format(w, e.Node, opts)
+}
+
+// Format writes the pretty-printed HTML of the document to w
+func (d Document) Format(w io.Writer, opts *FormatOptions) error {
+	return format(w, d.Node, opts)
+}
+
+func format(w io.Writer, n *html.Node, opts *FormatOptions) error {
+	f := &formatter{}
+	if opts != nil {
+		f.opts = *opts
+	}
+	if f.opts.Indent == "" {
+		f.opts.Indent = "  "
+	}
+	f.node(n, 0)
+	_, err := io.WriteString(w, f.b.String())
+	return err
+}
+
+type formatter struct {
+	opts FormatOptions
+	b    strings.Builder
+}
+
+// fmtToken is a word or a tag in an inline content
+type fmtToken struct {
+	s     string
+	space bool // there is white space before
+	br    bool // the line must be broken after
+}
+
+func (f *formatter) indent(depth int) string {
+	return strings.Repeat(f.opts.Indent, depth)
+}
+
+func (f *formatter) line(depth int, s string) {
+	f.b.WriteString(f.indent(depth))
+	f.b.WriteString(s)
+	f.b.WriteString("\n")
+}
+
+func (f *formatter) fits(s string) bool {
+	return f.opts.LineWidth <= 0 || utf8.RuneCountInString(s) <= f.opts.LineWidth
+}
+
+func (f *formatter) node(n *html.Node, depth int) {
+	switch n.Type {
+	case html.DocumentNode:
+		f.children(n, depth)
+	case html.ElementNode:
+		f.element(n, depth)
+	case html.TextNode:
+		f.fill(f.tokens(n, n.NextSibling), depth)
+	default:
+		f.line(depth, renderString(n))
+	}
+}
+
+func (f *formatter) element(n *html.Node, depth int) {
+	switch {
+	case isHTML(n, preserveElements) || isHTML(n, rawTextElements):
+		f.line(depth, renderString(n))
+	case n.FirstChild == nil:
+		f.line(depth, f.startTag(n, depth)+endTag(n))
+	case inlineContent(n):
+		toks := f.tokens(n.FirstChild, nil)
+		start, end := f.startTag(n, depth), endTag(n)
+		if s := start + joinTokens(toks) + end; !strings.Contains(start, "\n") && f.fits(f.indent(depth)+s) {
+			f.line(depth, s)
+			return
+		}
+		f.line(depth, start)
+		f.fill(toks, depth+1)
+		f.line(depth, end)
+	default:
+		f.line(depth, f.startTag(n, depth))
+		f.children(n, depth+1)
+		if end := endTag(n); end != "" {
+			f.line(depth, end)
+		}
+	}
+}
+
+// children writes the child nodes. the inline nodes in a row
+// are filled in lines and others are written on each own line
+func (f *formatter) children(n *html.Node, depth int) {
+	var first *html.Node
+	flush := func(next *html.Node) {
+		if first != nil {
+			f.fill(f.tokens(first, next), depth)
+			first = nil
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isInline(c) {
+			if first == nil {
+				first = c
+			}
+			continue
+		}
+		flush(c)
+		f.node(c, depth)
+	}
+	flush(nil)
+}
+
+// startTag returns the start tag of n that
+// attributes are wrapped if needed
+func (f *formatter) startTag(n *html.Node, depth int) string {
+	s := startTag(n)
+	switch {
+	case len(n.Attr) < 2 || f.opts.AttributeWrap == WrapNever:
+		return s
+	case f.opts.AttributeWrap == WrapAuto && f.fits(f.indent(depth)+s):
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString("<" + n.Data)
+	for _, a := range n.Attr {
+		b.WriteString("\n" + f.indent(depth+1) + attrName(a) + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	if n.Namespace != "" && n.FirstChild == nil {
+		b.WriteString("/")
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// isInline returns true if n can be laid out in a line
+func isInline(n *html.Node) bool {
+	switch n.Type {
+	case html.TextNode, html.CommentNode:
+		return true
+	case html.ElementNode:
+		if !isHTML(n, inlineElements) {
+			return false
+		}
+		return isHTML(n, preserveElements) || inlineContent(n)
+	}
+	return false
+}
+
+// inlineContent returns true if all of the child nodes are inline
+func inlineContent(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if !isInline(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// tokens splits the sibling nodes from first until last into words and tags
+func (f *formatter) tokens(first, last *html.Node) []fmtToken {
+	var (
+		toks  []fmtToken
+		space bool
+	)
+	emit := func(s string) {
+		toks = append(toks, fmtToken{s: s, space: space})
+		space = false
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			words := strings.Fields(n.Data)
+			if len(words) == 0 {
+				space = space || n.Data != ""
+				return
+			}
+			space = space || isSpaceRune(rune(n.Data[0]))
+			for i, w := range words {
+				if i > 0 {
+					space = true
+				}
+				emit(html.EscapeString(w))
+			}
+			space = isSpaceRune(rune(n.Data[len(n.Data)-1]))
+		case html.CommentNode:
+			emit(renderString(n))
+		case html.ElementNode:
+			if isHTML(n, preserveElements) {
+				emit(renderString(n))
+				return
+			}
+			emit(startTag(n))
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+			if end := endTag(n); end != "" {
+				emit(end)
+			}
+			if n.Namespace == "" && n.Data == "br" {
+				toks[len(toks)-1].br = true
+			}
+		}
+	}
+	for c := first; c != last; c = c.NextSibling {
+		walk(c)
+	}
+	return toks
+}
+
+func isSpaceRune(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
+
+// joinTokens joins the tokens in a line
+func joinTokens(toks []fmtToken) string {
+	var b strings.Builder
+	for i, t := range toks {
+		if i > 0 && t.space {
+			b.WriteString(" ")
+		}
+		b.WriteString(t.s)
+	}
+	return b.String()
+}
+
+// fill writes the tokens breaking lines at the white space
+// so as not to exceed the line width
+func (f *formatter) fill(toks []fmtToken, depth int) {
+	ind := f.indent(depth)
+	var line strings.Builder
+	brk := false
+	for _, t := range toks {
+		switch {
+		case line.Len() == 0:
+		case brk || (t.space && !f.fits(ind+line.String()+" "+t.s)):
+			f.line(depth, line.String())
+			line.Reset()
+		case t.space:
+			line.WriteString(" ")
+		}
+		line.WriteString(t.s)
+		brk = t.br
+	}
+	if line.Len() > 0 {
+		f.line(depth, line.String())
+	}
+}
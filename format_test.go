@@ -0,0 +1,92 @@
+package gohtml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	s := `<!DOCTYPE html><html><head><title>T</title><script>if (a<2) {
+  a++
+}</script></head><body><div class="a" id="b"><p>Hello   <b>bold</b>,
+ world. This is a long paragraph<br>next</p><pre>
+  keep   this
+</pre><ul><li>one</li><li>two <a href="x">link</a></li></ul></div></body></html>`
+
+	expect := `<!DOCTYPE html>
+<html>
+  <head>
+    <title>T</title>
+    <script>if (a<2) {
+  a++
+}</script>
+  </head>
+  <body>
+    <div class="a" id="b">
+      <p>
+        Hello <b>bold</b>, world. This
+        is a long paragraph<br>
+        next
+      </p>
+      <pre>  keep   this
+</pre>
+      <ul>
+        <li>one</li>
+        <li>
+          two <a href="x">link</a>
+        </li>
+      </ul>
+    </div>
+  </body>
+</html>
+`
+	doc, _ := Parse(strings.NewReader(s))
+	var buf bytes.Buffer
+	if err := doc.Format(&buf, &FormatOptions{LineWidth: 40}); err != nil {
+		t.Fatal(err)
+	}
+	if actual := buf.String(); actual != expect {
+		t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, expect)
+	}
+
+	// the formatted HTML has the same text
+	again, _ := Parse(&buf)
+	if a, b := again.QuerySelector("pre").TextContent(), doc.QuerySelector("pre").TextContent(); a != b {
+		t.Errorf("\ngot : %q, want: %q\n", a, b)
+	}
+}
+
+func TestFormatAttributeWrap(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<div id="a" class="b"><img src="c" alt="d"></div>`))
+	div := doc.QuerySelector("div")
+
+	tests := []struct {
+		opts   FormatOptions
+		expect string
+	}{
+		{
+			FormatOptions{},
+			"<div id=\"a\" class=\"b\"><img src=\"c\" alt=\"d\"></div>\n",
+		},
+		{
+			FormatOptions{Indent: "\t", AttributeWrap: WrapAlways},
+			"<div\n\tid=\"a\"\n\tclass=\"b\">\n\t<img src=\"c\" alt=\"d\">\n</div>\n",
+		},
+		{
+			FormatOptions{LineWidth: 20},
+			"<div\n  id=\"a\"\n  class=\"b\">\n  <img src=\"c\" alt=\"d\">\n</div>\n",
+		},
+		{
+			FormatOptions{LineWidth: 20, AttributeWrap: WrapNever},
+			"<div id=\"a\" class=\"b\">\n  <img src=\"c\" alt=\"d\">\n</div>\n",
+		},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		div.Format(&buf, &test.opts)
+		if actual := buf.String(); actual != test.expect {
+			t.Errorf("\n%d: got :\n%s\nwant:\n%s\n", i, actual, test.expect)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package gohtml
+
+import (
+	"errors"
+
+	"golang.org/x/net/html"
+)
+
+// history is the undo and redo stacks of a document.
+// each entry is the records of a transaction in the applied order
+type history struct {
+	undo [][]MutationRecord
+	redo [][]MutationRecord
+	// open is the number of the transactions not finished yet
+	open int
+	// limit is the max number of the entries of each stack, 0 is unlimited
+	limit int
+}
+
+// push appends the entry to the stack within the limit
+func (h *history) push(stack *[][]MutationRecord, records []MutationRecord) {
+	*stack = append(*stack, records)
+	h.trim(stack)
+}
+
+// trim drops the oldest entries of the stack over the limit. the stack
+// is copied then, not to keep the dropped records
+func (h *history) trim(stack *[][]MutationRecord) {
+	if h.limit > 0 && len(*stack) > h.limit {
+		*stack = append([][]MutationRecord(nil), (*stack)[len(*stack)-h.limit:]...)
+	}
+}
+
+// historyOf returns the history of the document n belongs to
+func historyOf(n *html.Node) *history {
+	d := ensureDocData(n)
+	if d.history == nil {
+		d.history = &history{}
+	}
+	return d.history
+}
+
+// Transaction records the changes made to the document through gohtml,
+// until Commit or Rollback. the changes made to the nodes that are not
+// in the document, such as the new element before appended, are not recorded
+type Transaction struct {
+	root     *html.Node
+	observer *MutationObserver
+	done     bool
+}
+
+// ErrTransactionDone is returned if the transaction has already been
+// committed or rolled back
+var ErrTransactionDone = errors.New("gohtml: transaction has already been done")
+
+// Begin starts a transaction. the transactions can be nested, the inner
+// transaction that is committed is undone together with the outer one.
+// the committed transactions are kept to undo until ClearHistory or
+// Release, so Release is needed when the document is no longer used
+func (d Document) Begin() *Transaction {
+	t := &Transaction{root: d.Node, observer: NewMutationObserver(nil)}
+	t.observer.Observe(d.Node, MutationObserverInit{
+		ChildList:     true,
+		Attributes:    true,
+		CharacterData: true,
+		Subtree:       true,
+	})
+	historyOf(d.Node).open++
+	return t
+}
+
+// finish stops recording and returns the records
+func (t *Transaction) finish() ([]MutationRecord, error) {
+	if t.done {
+		return nil, ErrTransactionDone
+	}
+	t.done = true
+	records := t.observer.TakeRecords()
+	t.observer.Disconnect()
+	historyOf(t.root).open--
+	return records, nil
+}
+
+// Commit finishes the transaction and pushes its changes to the undo
+// stack of the document, if it is not nested. the redo stack is cleared
+func (t *Transaction) Commit() error {
+	records, err := t.finish()
+	if err != nil {
+		return err
+	}
+	h := historyOf(t.root)
+	if h.open > 0 || len(records) == 0 {
+		return nil
+	}
+	h.push(&h.undo, records)
+	h.redo = nil
+	return nil
+}
+
+// Rollback finishes the transaction and reverts its changes.
+// an error is returned if the changes made outside the transaction
+// prevent it, and then the document is left as it was
+func (t *Transaction) Rollback() error {
+	records, err := t.finish()
+	if err != nil {
+		return err
+	}
+	_, err = revertAll(records)
+	return err
+}
+
+// CanUndo returns true if there is a committed transaction to undo
+func (d Document) CanUndo() bool {
+	data := lookupDocData(d.Node)
+	return data != nil && data.history != nil && len(data.history.undo) > 0
+}
+
+// CanRedo returns true if there is an undone transaction to redo
+func (d Document) CanRedo() bool {
+	data := lookupDocData(d.Node)
+	return data != nil && data.history != nil && len(data.history.redo) > 0
+}
+
+// Undo reverts the last committed transaction. it does nothing if
+// there is nothing to undo. the changes made outside transactions are
+// not undone, and an error is returned if they prevent reverting
+func (d Document) Undo() error {
+	data := lookupDocData(d.Node)
+	if data == nil || data.history == nil {
+		return nil
+	}
+	h := data.history
+	return h.move(&h.undo, &h.redo)
+}
+
+// Redo applies the last undone transaction again
+func (d Document) Redo() error {
+	data := lookupDocData(d.Node)
+	if data == nil || data.history == nil {
+		return nil
+	}
+	h := data.history
+	return h.move(&h.redo, &h.undo)
+}
+
+// SetHistoryLimit sets the max number of the transactions kept to undo
+// and redo. the oldest ones over the limit are discarded. 0 or less
+// removes the limit, which is the default
+func (d Document) SetHistoryLimit(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	h := historyOf(d.Node)
+	h.limit = limit
+	h.trim(&h.undo)
+	h.trim(&h.redo)
+}
+
+// ClearHistory discards the transactions kept to undo and redo.
+// the transactions not finished yet are not affected
+func (d Document) ClearHistory() {
+	data := lookupDocData(d.Node)
+	if data == nil || data.history == nil {
+		return
+	}
+	data.history.undo, data.history.redo = nil, nil
+}
+
+// move reverts the last entry of from and pushes the reverse to to
+func (h *history) move(from, to *[][]MutationRecord) error {
+	if len(*from) == 0 {
+		return nil
+	}
+	records := (*from)[len(*from)-1]
+	inverse, err := revertAll(records)
+	if err != nil {
+		return err
+	}
+	(*from)[len(*from)-1] = nil
+	*from = (*from)[:len(*from)-1]
+	h.push(to, inverse)
+	return nil
+}
+
+// revertAll reverts the records from the last one, and returns the
+// records that reverse it in the applied order. if a record cannot be
+// reverted, the reverted ones are applied again
+func revertAll(records []MutationRecord) ([]MutationRecord, error) {
+	var inverse []MutationRecord
+	for i := len(records) - 1; i >= 0; i-- {
+		r, err := revert(records[i])
+		if err != nil {
+			revertAll(inverse)
+			return nil, err
+		}
+		inverse = append(inverse, r)
+	}
+	return inverse, nil
+}
+
+// revert undoes the change of the record and returns the record of the undo
+func revert(r MutationRecord) (MutationRecord, error) {
+	n := r.Target
+	switch r.Type {
+	case "characterData":
+		inverse := r
+		inverse.oldValue = n.Data
+		mutateData(n, r.oldValue)
+		return inverse, nil
+	case "attributes":
+		// only the attribute of the record is restored, the other
+		// attributes may have been changed afterward
+		inverse := r
+		inverse.oldValue, inverse.oldIndex = "", attrIndex(n, r.AttributeNamespace, r.AttributeName)
+		if inverse.oldIndex >= 0 {
+			inverse.oldValue = n.Attr[inverse.oldIndex].Val
+		}
+		mutateAttr(n, r.AttributeNamespace, r.AttributeName, func() {
+			i := inverse.oldIndex
+			switch {
+			case r.oldIndex < 0:
+				if i >= 0 {
+					n.Attr = append(n.Attr[:i], n.Attr[i+1:]...)
+				}
+			case i >= 0:
+				n.Attr[i].Val = r.oldValue
+			default:
+				a := html.Attribute{Namespace: r.AttributeNamespace, Key: r.AttributeName, Val: r.oldValue}
+				i = r.oldIndex
+				if i > len(n.Attr) {
+					i = len(n.Attr)
+				}
+				n.Attr = append(n.Attr[:i], append([]html.Attribute{a}, n.Attr[i:]...)...)
+			}
+		})
+		return inverse, nil
+	}
+
+	for _, c := range r.AddedNodes {
+		if c.Parent != n {
+			return r, errors.New("gohtml: cannot revert, the added node has been moved")
+		}
+	}
+	if r.NextSibling != nil && r.NextSibling.Parent != n ||
+		r.PreviousSibling != nil && r.PreviousSibling.Parent != n {
+		return r, errors.New("gohtml: cannot revert, the sibling has been moved")
+	}
+	for _, c := range r.RemovedNodes {
+		if c.Parent != nil {
+			return r, errors.New("gohtml: cannot revert, the removed node has been inserted")
+		}
+	}
+
+	mutateChildren(n, func() error {
+		for _, c := range r.AddedNodes {
+			n.RemoveChild(c)
+		}
+		next := r.NextSibling
+		if next == nil && r.PreviousSibling != nil {
+			next = r.PreviousSibling.NextSibling
+		}
+		for _, c := range r.RemovedNodes {
+			n.InsertBefore(c, next)
+		}
+		return nil
+	})
+	inverse := r
+	inverse.AddedNodes, inverse.RemovedNodes = r.RemovedNodes, r.AddedNodes
+	return inverse, nil
+}
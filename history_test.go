@@ -0,0 +1,163 @@
+package gohtml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransaction(t *testing.T) {
+	const s = `<html><head></head><body><div id="d" class="a"><p>one</p><p>two</p></div></body></html>`
+	doc, _ := Parse(strings.NewReader(s))
+	defer doc.Release()
+	div := doc.GetElementById("d")
+	html := func() string { return doc.DocumentElement().OuterHTML() }
+
+	tx := doc.Begin()
+	div.SetAttribute("class", "b")
+	div.SetAttribute("title", "t")
+	div.RemoveAttribute("id")
+	div.FirstElementChild().Remove()
+	(&Element{Node: div.FirstElementChild().Node.FirstChild}).NodeValue("TWO")
+	div.InsertAdjacentHTML(Afterbegin, "<h1>x</h1><hr>")
+	doc.Body().AppendChild(CreateElement("footer"))
+	changed := html()
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if actual := html(); actual != s {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, s)
+	}
+	if doc.CanUndo() {
+		t.Errorf("\nthe rolled back transaction is undoable\n")
+	}
+	if err := tx.Commit(); err != ErrTransactionDone {
+		t.Errorf("\ngot : %v, want: %v\n", err, ErrTransactionDone)
+	}
+
+	tx = doc.Begin()
+	div.SetAttribute("class", "b")
+	div.SetAttribute("title", "t")
+	div.RemoveAttribute("id")
+	inner := doc.Begin()
+	div.FirstElementChild().Remove()
+	(&Element{Node: div.FirstElementChild().Node.FirstChild}).NodeValue("TWO")
+	inner.Commit()
+	div.InsertAdjacentHTML(Afterbegin, "<h1>x</h1><hr>")
+	doc.Body().AppendChild(CreateElement("footer"))
+	tx.Commit()
+
+	if actual := html(); actual != changed {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, changed)
+	}
+	if err := doc.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if actual := html(); actual != s {
+		t.Errorf("\nundo: got : %s\nwant: %s\n", actual, s)
+	}
+	if doc.CanUndo() || !doc.CanRedo() {
+		t.Errorf("\nthe nested transaction is in the undo stack\n")
+	}
+	if err := doc.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if actual := html(); actual != changed {
+		t.Errorf("\nredo: got : %s\nwant: %s\n", actual, changed)
+	}
+	if err := doc.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if actual := html(); actual != s {
+		t.Errorf("\nundo again: got : %s\nwant: %s\n", actual, s)
+	}
+
+	tx = doc.Begin()
+	div.SetAttribute("class", "c")
+	tx.Commit()
+	if doc.CanRedo() {
+		t.Errorf("\nthe redo stack is not cleared by Commit\n")
+	}
+}
+
+func TestUndoConflict(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<div id="d"><p>one</p></div>`))
+	defer doc.Release()
+	div := doc.GetElementById("d")
+
+	tx := doc.Begin()
+	div.SetAttribute("class", "a")
+	p := CreateElement("p")
+	div.AppendChild(p)
+	tx.Commit()
+
+	// the change outside the transaction prevents undo
+	p.Remove()
+	before := div.OuterHTML()
+	if err := doc.Undo(); err == nil {
+		t.Errorf("\nexpected an error\n")
+	}
+	if actual := div.OuterHTML(); actual != before {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, before)
+	}
+}
+
+func TestUndoAttributes(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<div id="d" a="1" x="y"></div>`))
+	defer doc.Release()
+	div := doc.GetElementById("d")
+
+	tx := doc.Begin()
+	div.SetAttribute("a", "2")
+	div.SetAttribute("c", "3")
+	div.RemoveAttribute("x")
+	tx.Commit()
+
+	// the attribute changed outside the transaction is kept
+	div.SetAttribute("b", "outside")
+	if err := doc.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	expect := `<div id="d" a="1" x="y" b="outside"></div>`
+	if actual := div.OuterHTML(); actual != expect {
+		t.Errorf("\nundo: got : %s\nwant: %s\n", actual, expect)
+	}
+	if err := doc.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	expect = `<div id="d" a="2" c="3" b="outside"></div>`
+	if actual := div.OuterHTML(); actual != expect {
+		t.Errorf("\nredo: got : %s\nwant: %s\n", actual, expect)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<div id="d"></div>`))
+	defer doc.Release()
+	div := doc.GetElementById("d")
+
+	for _, v := range []string{"1", "2", "3"} {
+		tx := doc.Begin()
+		div.SetAttribute("v", v)
+		tx.Commit()
+	}
+	doc.SetHistoryLimit(2)
+	for doc.CanUndo() {
+		if err := doc.Undo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if actual := div.GetAttribute("v"); actual != "1" {
+		t.Errorf("\ngot : %s, want: 1\n", actual)
+	}
+
+	tx := doc.Begin()
+	div.SetAttribute("v", "4")
+	tx.Commit()
+	doc.ClearHistory()
+	if doc.CanUndo() || doc.CanRedo() {
+		t.Errorf("\nthe history must be cleared\n")
+	}
+	if err := doc.Undo(); err != nil || div.GetAttribute("v") != "4" {
+		t.Errorf("\nnothing must be undone: %v\n", err)
+	}
+}
@@ -0,0 +1,498 @@
+package gohtml
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/saihon/gohtml/attr"
+)
+
+// Image is the <img> element
+type Image struct {
+	Element
+}
+
+// Image returns the element as "*Image" if it is <img>, otherwise nil
+func (e Element) Image() *Image {
+	if e.Node == nil || e.Node.Type != html.ElementNode || e.Node.DataAtom != atom.Img {
+		return nil
+	}
+	return &Image{e}
+}
+
+// SourceSize is a source size of sizes. Media is the media condition,
+// empty if the size is used unconditionally
+type SourceSize struct {
+	Media string
+	Size  string
+}
+
+// Srcset returns the candidates of the srcset attribute
+func (i Image) Srcset() []ImageCandidate {
+	return ParseSrcset(attr.Get(i.Node, "srcset"))
+}
+
+// Sizes returns the source sizes of the sizes attribute
+func (i Image) Sizes() []SourceSize {
+	return ParseSizes(attr.Get(i.Node, "sizes"))
+}
+
+// Picture returns the parent <picture>, or nil
+func (i Image) Picture() *Element {
+	if p := i.Node.Parent; p != nil && p.Type == html.ElementNode && p.DataAtom == atom.Picture {
+		return &Element{p}
+	}
+	return nil
+}
+
+// Sources returns <source> of <picture> that are before the image,
+// which are the alternative sources of the image
+func (i Image) Sources() Collection {
+	var c Collection
+	if i.Picture() == nil {
+		return c
+	}
+	for n := i.Node.Parent.FirstChild; n != nil && n != i.Node; n = n.NextSibling {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Source {
+			c.Nodes = append(c.Nodes, n)
+		}
+	}
+	return c
+}
+
+// SelectCandidate selects the candidate like browsers for the viewport
+// width in CSS pixels and the device pixel ratio. the first <source> of
+// <picture> that has srcset and matches the media and the type is used,
+// otherwise srcset and src of the image. the width descriptors are
+// converted to the densities by sizes, and the candidate of the smallest
+// density that is not less than dpr is selected, or the largest one.
+// the Density of the returned candidate is the effective density.
+// it returns nil if the image has no candidate
+func (i Image) SelectCandidate(viewportWidth, dpr float64) *ImageCandidate {
+	candidates, sizes := i.sourceSet(viewportWidth, dpr)
+	if len(candidates) == 0 {
+		return nil
+	}
+	size := sourceSize(sizes, viewportWidth, dpr)
+
+	var list []ImageCandidate
+	seen := map[float64]bool{}
+	for _, c := range candidates {
+		if c.Width > 0 {
+			c.Density = float64(c.Width) / size
+		} else if c.Density == 0 {
+			c.Density = 1
+		}
+		// the later candidates of the same density are ignored
+		if seen[c.Density] {
+			continue
+		}
+		seen[c.Density] = true
+		list = append(list, c)
+	}
+
+	var best *ImageCandidate
+	for k := range list {
+		c := &list[k]
+		switch {
+		case best == nil:
+			best = c
+		case best.Density < dpr:
+			if c.Density > best.Density {
+				best = c
+			}
+		case c.Density >= dpr && c.Density < best.Density:
+			best = c
+		}
+	}
+	return best
+}
+
+// sourceSet returns the candidates and sizes of the source
+// of the image that is used for the viewport
+func (i Image) sourceSet(width, dpr float64) ([]ImageCandidate, string) {
+	for _, n := range i.Sources().Nodes {
+		candidates := ParseSrcset(attr.Get(n, "srcset"))
+		if len(candidates) == 0 {
+			continue
+		}
+		if media, ok := attr.GetNode(n, "media"); ok && !matchMedia(media.Val, width, dpr) {
+			continue
+		}
+		if typ, ok := attr.GetNode(n, "type"); ok && !supportedImageType(typ.Val) {
+			continue
+		}
+		return candidates, attr.Get(n, "sizes")
+	}
+
+	candidates := i.Srcset()
+	if src := attr.Get(i.Node, "src"); src != "" {
+		// src is 1x unless srcset has 1x or the width descriptors
+		add := true
+		for _, c := range candidates {
+			if c.Width > 0 || c.Density == 0 || c.Density == 1 {
+				add = false
+			}
+		}
+		if add {
+			candidates = append(candidates, ImageCandidate{URL: src})
+		}
+	}
+	return candidates, attr.Get(i.Node, "sizes")
+}
+
+// imageTypes is the MIME types of the images that browsers support
+var imageTypes = map[string]bool{
+	"image/apng":               true,
+	"image/avif":               true,
+	"image/bmp":                true,
+	"image/gif":                true,
+	"image/jpeg":               true,
+	"image/png":                true,
+	"image/svg+xml":            true,
+	"image/webp":               true,
+	"image/x-icon":             true,
+	"image/vnd.microsoft.icon": true,
+}
+
+func supportedImageType(s string) bool {
+	s, _, _ = strings.Cut(s, ";")
+	return imageTypes[strings.ToLower(strings.TrimSpace(s))]
+}
+
+// ParseSizes parses sizes into the source sizes. the sizes that are not
+// a valid length are dropped
+func ParseSizes(s string) []SourceSize {
+	var list []SourceSize
+	for _, part := range splitTopLevel(s, ',') {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		// the last component is the size, which can be a function like calc()
+		j := strings.LastIndexFunc(part, isCSSSpace) + 1
+		if strings.HasSuffix(part, ")") {
+			depth := 0
+			for j = len(part) - 1; j >= 0; j-- {
+				if part[j] == ')' {
+					depth++
+				} else if part[j] == '(' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			for j > 0 && (isLetter(part[j-1]) || part[j-1] == '-') {
+				j--
+			}
+			if j < 0 {
+				continue
+			}
+		}
+		size := SourceSize{Media: strings.TrimSpace(part[:j]), Size: part[j:]}
+		if _, ok := cssLength(size.Size, 0); !ok {
+			continue
+		}
+		list = append(list, size)
+	}
+	return list
+}
+
+// sourceSize returns the size of the first source size
+// that matches the viewport, or the viewport width
+func sourceSize(sizes string, width, dpr float64) float64 {
+	for _, s := range ParseSizes(sizes) {
+		if s.Media == "" || matchMedia(s.Media, width, dpr) {
+			if v, ok := cssLength(s.Size, width); ok {
+				return v
+			}
+		}
+	}
+	return width
+}
+
+func isCSSSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r'
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// splitTopLevel splits s by sep out of the parentheses
+func splitTopLevel(s string, sep byte) []string {
+	var list []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case sep:
+			if depth == 0 {
+				list = append(list, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(list, s[start:])
+}
+
+// cssUnits is the absolute and the font-relative units in CSS pixels.
+// the font size is assumed to be 16px
+var cssUnits = map[string]float64{
+	"px":  1,
+	"em":  16,
+	"rem": 16,
+	"in":  96,
+	"cm":  96 / 2.54,
+	"mm":  96 / 25.4,
+	"q":   96 / 101.6,
+	"pt":  96.0 / 72,
+	"pc":  16,
+}
+
+var reCSSNumber = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?([a-zA-Z]*)`)
+
+// cssLength evaluates the length of CSS in pixels, which is a number
+// with the unit, 0 or calc(). vw is relative to width. the negative
+// length is invalid, but the negative result of calc() is clamped to 0
+func cssLength(s string, width float64) (float64, bool) {
+	p := &calcParser{s: strings.ToLower(strings.TrimSpace(s)), width: width}
+	v, length, ok := p.factor()
+	p.space()
+	if !ok || p.i != len(p.s) || !length && v != 0 {
+		return 0, false
+	}
+	if v < 0 {
+		if !strings.HasPrefix(p.s, "calc(") {
+			return 0, false
+		}
+		v = 0
+	}
+	return v, true
+}
+
+// calcParser evaluates the expression of calc()
+type calcParser struct {
+	s     string
+	i     int
+	width float64
+}
+
+func (p *calcParser) space() {
+	for p.i < len(p.s) && isCSSSpace(rune(p.s[p.i])) {
+		p.i++
+	}
+}
+
+// expr returns the value and true if it is a length
+func (p *calcParser) expr() (float64, bool, bool) {
+	v, length, ok := p.term()
+	for ok {
+		p.space()
+		if p.i >= len(p.s) || (p.s[p.i] != '+' && p.s[p.i] != '-') {
+			break
+		}
+		op := p.s[p.i]
+		p.i++
+		w, wl, wok := p.term()
+		if !wok || wl != length {
+			return 0, false, false
+		}
+		if op == '+' {
+			v += w
+		} else {
+			v -= w
+		}
+	}
+	return v, length, ok
+}
+
+func (p *calcParser) term() (float64, bool, bool) {
+	v, length, ok := p.factor()
+	for ok {
+		p.space()
+		if p.i >= len(p.s) || (p.s[p.i] != '*' && p.s[p.i] != '/') {
+			break
+		}
+		op := p.s[p.i]
+		p.i++
+		w, wl, wok := p.factor()
+		// a length can be multiplied or divided by a number only
+		if !wok || wl && (length || op == '/') || op == '/' && w == 0 {
+			return 0, false, false
+		}
+		if op == '*' {
+			v *= w
+		} else {
+			v /= w
+		}
+		length = length || wl
+	}
+	return v, length, ok
+}
+
+func (p *calcParser) factor() (float64, bool, bool) {
+	p.space()
+	rest := p.s[p.i:]
+	if strings.HasPrefix(rest, "calc(") || strings.HasPrefix(rest, "(") {
+		p.i += strings.IndexByte(rest, '(') + 1
+		v, length, ok := p.expr()
+		p.space()
+		if !ok || p.i >= len(p.s) || p.s[p.i] != ')' {
+			return 0, false, false
+		}
+		p.i++
+		return v, length, true
+	}
+
+	m := reCSSNumber.FindStringSubmatch(rest)
+	if m == nil {
+		return 0, false, false
+	}
+	p.i += len(m[0])
+	unit := m[3]
+	v, err := strconv.ParseFloat(m[0][:len(m[0])-len(unit)], 64)
+	if err != nil {
+		return 0, false, false
+	}
+	switch {
+	case unit == "":
+		return v, false, true
+	case unit == "vw":
+		return v * p.width / 100, true, true
+	case cssUnits[unit] != 0:
+		return v * cssUnits[unit], true, true
+	}
+	return 0, false, false
+}
+
+// reMediaAnd splits the media query by "and"
+var reMediaAnd = regexp.MustCompile(`\s+and\s+`)
+
+// matchMedia returns true if the media query list matches the screen of
+// the viewport width and the device pixel ratio. it supports the media
+// types, not, and, width and resolution, the others do not match
+func matchMedia(s string, width, dpr float64) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return true
+	}
+	for _, q := range splitTopLevel(s, ',') {
+		if matchMediaQuery(strings.TrimSpace(q), width, dpr) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchMediaQuery(q string, width, dpr float64) bool {
+	not := false
+	if rest, ok := strings.CutPrefix(q, "not "); ok {
+		not, q = true, strings.TrimSpace(rest)
+	} else if rest, ok := strings.CutPrefix(q, "only "); ok {
+		q = strings.TrimSpace(rest)
+	}
+	match := q != ""
+	for _, part := range reMediaAnd.Split(q, -1) {
+		match = match && matchMediaPart(part, width, dpr)
+	}
+	return match != not
+}
+
+func matchMediaPart(part string, width, dpr float64) bool {
+	switch part {
+	case "all", "screen":
+		return true
+	}
+	if !strings.HasPrefix(part, "(") || !strings.HasSuffix(part, ")") {
+		return false
+	}
+	inner := strings.TrimSpace(part[1 : len(part)-1])
+	if rest, ok := strings.CutPrefix(inner, "not "); ok {
+		return !matchMediaPart(strings.TrimSpace(rest), width, dpr)
+	}
+	if strings.HasPrefix(inner, "(") {
+		return matchMediaQuery(inner, width, dpr)
+	}
+
+	name, value, op := inner, "", ""
+	if i := strings.IndexByte(inner, ':'); i >= 0 {
+		name, value = strings.TrimSpace(inner[:i]), strings.TrimSpace(inner[i+1:])
+		op = "="
+		if n, ok := strings.CutPrefix(name, "min-"); ok {
+			name, op = n, ">="
+		} else if n, ok := strings.CutPrefix(name, "max-"); ok {
+			name, op = n, "<="
+		}
+	} else if i := strings.IndexAny(inner, "<>="); i >= 0 {
+		name = strings.TrimSpace(inner[:i])
+		op = inner[i : i+1]
+		if i+1 < len(inner) && inner[i+1] == '=' {
+			op += "="
+		}
+		value = strings.TrimSpace(inner[i+len(op):])
+	}
+
+	var actual, expect float64
+	var ok bool
+	switch name {
+	case "width":
+		actual = width
+		expect, ok = cssLength(value, width)
+	case "resolution":
+		actual = dpr
+		expect, ok = resolution(value)
+	case "color":
+		return op == ""
+	default:
+		return false
+	}
+	if op == "" {
+		return actual != 0
+	}
+	if !ok {
+		return false
+	}
+	switch op {
+	case ">=":
+		return actual >= expect
+	case "<=":
+		return actual <= expect
+	case ">":
+		return actual > expect
+	case "<":
+		return actual < expect
+	}
+	return math.Abs(actual-expect) < 1e-9
+}
+
+// resolution parses the resolution of CSS in dppx
+func resolution(s string) (float64, bool) {
+	m := reCSSNumber.FindStringSubmatch(s)
+	if m == nil || len(m[0]) != len(s) {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s[:len(s)-len(m[3])], 64)
+	if err != nil {
+		return 0, false
+	}
+	switch m[3] {
+	case "dppx", "x":
+		return v, true
+	case "dpi":
+		return v / 96, true
+	case "dpcm":
+		return v * 2.54 / 96, true
+	}
+	return 0, false
+}
@@ -0,0 +1,144 @@
+package gohtml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSrcset(t *testing.T) {
+	actual := ParseSrcset("a.png, b.png 2x, c.png 100w, d.png 100w 50h, e.png 1x 2x, f.png 10h, g.png 0w, h.png 1.5x, i.png 3X")
+	expect := []ImageCandidate{
+		{URL: "a.png"},
+		{URL: "b.png", Density: 2},
+		{URL: "c.png", Width: 100},
+		{URL: "d.png", Width: 100, Height: 50},
+		{URL: "h.png", Density: 1.5},
+	}
+	if !reflect.DeepEqual(actual, expect) {
+		t.Errorf("\ngot : %+v\nwant: %+v\n", actual, expect)
+	}
+}
+
+func TestParseSizes(t *testing.T) {
+	actual := ParseSizes("(max-width: 600px) 100vw, (min-width: 601px) and (max-width: 900px) calc(50vw - 2em), bad, -10px, 300px")
+	expect := []SourceSize{
+		{"(max-width: 600px)", "100vw"},
+		{"(min-width: 601px) and (max-width: 900px)", "calc(50vw - 2em)"},
+		{"", "300px"},
+	}
+	if !reflect.DeepEqual(actual, expect) {
+		t.Errorf("\ngot : %+v\nwant: %+v\n", actual, expect)
+	}
+
+	tests := []struct {
+		input  string
+		expect float64
+		ok     bool
+	}{
+		{"100px", 100, true},
+		{"2em", 32, true},
+		{"50vw", 400, true},
+		{"calc(50vw - 2em)", 368, true},
+		{"calc((100vw - 200px) / 2)", 300, true},
+		{"calc(2 * 10px)", 20, true},
+		{"calc(10px - 100vw)", 0, true},
+		{"0", 0, true},
+		{"10", 0, false},
+		{"calc(10px * 10px)", 0, false},
+		{"10vh", 0, false},
+	}
+	for _, tt := range tests {
+		if actual, ok := cssLength(tt.input, 800); actual != tt.expect || ok != tt.ok {
+			t.Errorf("\n%s: got : %v %v, want: %v %v\n", tt.input, actual, ok, tt.expect, tt.ok)
+		}
+	}
+}
+
+func TestMatchMedia(t *testing.T) {
+	tests := []struct {
+		query  string
+		expect bool
+	}{
+		{"", true},
+		{"screen", true},
+		{"print", false},
+		{"(min-width: 600px)", true},
+		{"(max-width: 600px)", false},
+		{"screen and (min-width: 40em) and (max-width: 1000px)", true},
+		{"print, (width >= 800px)", true},
+		{"(width > 800px)", false},
+		{"not screen and (max-width: 600px)", true},
+		{"(not (min-width: 600px))", false},
+		{"(min-resolution: 2dppx)", true},
+		{"(min-resolution: 192dpi)", true},
+		{"(max-resolution: 1x)", false},
+		{"(orientation: landscape)", false},
+	}
+	for _, tt := range tests {
+		if actual := matchMedia(tt.query, 800, 2); actual != tt.expect {
+			t.Errorf("\n%q: got : %v, want: %v\n", tt.query, actual, tt.expect)
+		}
+	}
+}
+
+func TestImageSelectCandidate(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`
+<img id="density" src="a.png" srcset="a-2x.png 2x, a-3x.png 3x">
+<img id="width" src="b.png" srcset="b-400.png 400w, b-800.png 800w, b-1600.png 1600w" sizes="(max-width: 600px) 100vw, 50vw">
+<img id="src" src="c.png">
+<img id="none">
+<picture>
+<source srcset="d.avif" type="image/avif">
+<source srcset="d.jxl" type="image/jxl">
+<source srcset="d-small.webp 1x, d-small-2x.webp 2x" media="(max-width: 600px)" type="image/webp">
+<source srcset="">
+<img id="picture" src="d.png">
+</picture>
+<picture><source srcset="e.jxl" type="image/jxl"><img id="fallback" src="e.png" srcset="e-2x.png 2x"></picture>`))
+
+	tests := []struct {
+		id      string
+		width   float64
+		dpr     float64
+		expect  string
+		density float64
+	}{
+		{"density", 1000, 1, "a.png", 1},
+		{"density", 1000, 1.5, "a-2x.png", 2},
+		{"density", 1000, 4, "a-3x.png", 3},
+		{"width", 500, 1, "b-800.png", 1.6},
+		{"width", 500, 2, "b-1600.png", 3.2},
+		{"width", 1000, 1, "b-800.png", 1.6},
+		{"width", 3200, 1, "b-1600.png", 1},
+		{"src", 1000, 2, "c.png", 1},
+		{"picture", 500, 1, "d.avif", 1},
+		{"fallback", 1000, 1, "e.png", 1},
+		{"fallback", 1000, 2, "e-2x.png", 2},
+	}
+	for _, tt := range tests {
+		c := doc.GetElementById(tt.id).Image().SelectCandidate(tt.width, tt.dpr)
+		if c == nil || c.URL != tt.expect || c.Density != tt.density {
+			t.Errorf("\n%s %v %v: got : %+v, want: %s %v\n", tt.id, tt.width, tt.dpr, c, tt.expect, tt.density)
+		}
+	}
+	if doc.GetElementById("none").Image().SelectCandidate(1000, 1) != nil {
+		t.Errorf("\nthe image without the source must select nothing\n")
+	}
+
+	img := doc.GetElementById("picture").Image()
+	if img.Picture() == nil || img.Sources().Length() != 4 || doc.GetElementById("src").Image().Picture() != nil {
+		t.Errorf("\nthe sources are wrong\n")
+	}
+	if doc.Body().Image() != nil {
+		t.Errorf("\n<body> must not be an image\n")
+	}
+	// the sources of the unsupported types are skipped
+	img.Sources().Get(0).SetAttribute("type", "image/jxl")
+	if c := img.SelectCandidate(500, 2); c == nil || c.URL != "d-small-2x.webp" {
+		t.Errorf("\ngot : %+v, want: d-small-2x.webp\n", c)
+	}
+	if c := img.SelectCandidate(1000, 2); c == nil || c.URL != "d.png" {
+		t.Errorf("\ngot : %+v, want: d.png\n", c)
+	}
+}
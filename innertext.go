@@ -0,0 +1,255 @@
+package gohtml
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/saihon/gohtml/attr"
+	"github.com/saihon/gohtml/utils"
+)
+
+// PlainTextOptions is the options for PlainText
+type PlainTextOptions struct {
+	// Width is the maximum width of a line. 0 means unlimited.
+	// lines are wrapped at the white space, except in <pre>
+	Width int
+	// LinkFootnotes writes the number like [1] before each link
+	// and the list of the URLs at the end, like lynx -dump
+	LinkFootnotes bool
+}
+
+// InnerText set or get text to an element like innerText of JavaScript.
+// getting text renders the element as plain text, see PlainText.
+// setting text replaces the child nodes, line feeds become <br>
+func (e Element) InnerText(text ...string) string {
+	if text == nil {
+		return e.PlainText(nil)
+	}
+
+	s := strings.Join(text, " ")
+	mutateChildren(e.Node, func() error {
+		utils.Empty(e.Node)
+		lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+		for i, line := range lines {
+			if i > 0 {
+				e.Node.AppendChild(&html.Node{Type: html.ElementNode, DataAtom: atom.Br, Data: "br"})
+			}
+			if line != "" {
+				e.Node.AppendChild(&html.Node{Type: html.TextNode, Data: line})
+			}
+		}
+		return nil
+	})
+	return s
+}
+
+// PlainText returns the text of the element as it is rendered.
+// block elements are on each own line, <br> is the line feed,
+// white space is collapsed except in <pre>, the hidden elements
+// are skipped and the table cells are separated by tab.
+// if the element itself is hidden, it returns the TextContent
+func (e Element) PlainText(opts *PlainTextOptions) string {
+	if e.Node.Type == html.ElementNode && hiddenElement(e.Node) {
+		return utils.Text(e.Node)
+	}
+	return plainText(e.Node, opts)
+}
+
+// PlainText returns the text of the body as it is rendered
+func (d Document) PlainText(opts *PlainTextOptions) string {
+	if body := d.Body(); body != nil {
+		return plainText(body.Node, opts)
+	}
+	return plainText(d.Node, opts)
+}
+
+func plainText(n *html.Node, opts *PlainTextOptions) string {
+	w := &textWriter{}
+	if opts != nil {
+		w.opts = *opts
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c, false)
+	}
+	s := w.b.String()
+
+	if len(w.links) > 0 {
+		var b strings.Builder
+		b.WriteString(s)
+		b.WriteString("\n\nReferences\n\n")
+		for i, href := range w.links {
+			b.WriteString(strconv.Itoa(i+1) + ". " + href + "\n")
+		}
+		s = b.String()
+	}
+	return s
+}
+
+// textSkip are the elements which are not rendered
+var textSkip = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true,
+	"noscript": true, "iframe": true, "title": true, "datalist": true,
+	"input": true, "textarea": true, "img": true, "video": true, "audio": true,
+}
+
+// hiddenElement returns true if the element has the hidden attribute
+// or display:none in the inline style
+func hiddenElement(n *html.Node) bool {
+	if attr.Has(n, "hidden") {
+		return true
+	}
+	style := strings.ToLower(attr.Get(n, "style"))
+	style = strings.Map(func(r rune) rune {
+		if isSpaceRune(r) {
+			return -1
+		}
+		return r
+	}, style)
+	for _, decl := range strings.Split(style, ";") {
+		if strings.HasPrefix(decl, "display:none") {
+			return true
+		}
+	}
+	return false
+}
+
+type textWriter struct {
+	opts  PlainTextOptions
+	b     strings.Builder
+	links []string
+
+	breaks int    // the required line breaks before the next text
+	space  bool   // the collapsed white space before the next text
+	prefix string // the link number written with the next word
+	column int
+}
+
+// lineBreak requires count line breaks before the next text
+func (w *textWriter) lineBreak(count int) {
+	if count > w.breaks {
+		w.breaks = count
+	}
+	w.space = false
+}
+
+func (w *textWriter) write(s string) {
+	if w.breaks > 0 && w.b.Len() > 0 {
+		w.b.WriteString(strings.Repeat("\n", w.breaks))
+		w.column = 0
+	}
+	w.breaks = 0
+	w.b.WriteString(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		w.column = utf8.RuneCountInString(s[i+1:])
+	} else {
+		w.column += utf8.RuneCountInString(s)
+	}
+}
+
+// word writes the word that a collapsed space may be before
+func (w *textWriter) word(s string) {
+	s, w.prefix = w.prefix+s, ""
+	if w.space && w.column > 0 && w.breaks == 0 {
+		if w.opts.Width > 0 && w.column+1+utf8.RuneCountInString(s) > w.opts.Width {
+			w.write("\n")
+		} else {
+			w.write(" ")
+		}
+	}
+	w.space = false
+	w.write(s)
+}
+
+// text writes the text that the white space is collapsed
+func (w *textWriter) text(s string) {
+	if s == "" {
+		return
+	}
+	if isSpaceRune(rune(s[0])) {
+		w.space = true
+	}
+	words := strings.FieldsFunc(s, isSpaceRune)
+	for i, word := range words {
+		if i > 0 {
+			w.space = true
+		}
+		w.word(word)
+	}
+	if len(words) > 0 && isSpaceRune(rune(s[len(s)-1])) {
+		w.space = true
+	}
+}
+
+func (w *textWriter) node(n *html.Node, pre bool) {
+	switch n.Type {
+	case html.TextNode:
+		if pre {
+			w.space = false
+			w.write(w.prefix + n.Data)
+			w.prefix = ""
+		} else {
+			w.text(n.Data)
+		}
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	if hiddenElement(n) || (n.Namespace == "" && textSkip[n.Data]) {
+		return
+	}
+
+	if n.Namespace == "" {
+		switch n.Data {
+		case "br":
+			w.space = false
+			w.write("\n")
+			return
+		case "td", "th":
+			w.children(n, pre)
+			if next := nextElement(n); next != nil && isHTML(next, map[string]bool{"td": true, "th": true}) {
+				w.space = false
+				w.write("\t")
+			}
+			return
+		case "a":
+			if w.opts.LinkFootnotes && attr.Has(n, "href") {
+				w.links = append(w.links, attr.Get(n, "href"))
+				w.prefix = "[" + strconv.Itoa(len(w.links)) + "]"
+			}
+		}
+	}
+
+	block := n.Namespace == "" && !inlineElements[n.Data]
+	count := 1
+	if n.Namespace == "" && n.Data == "p" {
+		count = 2
+	}
+	if block {
+		w.lineBreak(count)
+	}
+	w.children(n, pre || isHTML(n, preserveElements))
+	if block {
+		w.lineBreak(count)
+	}
+}
+
+func (w *textWriter) children(n *html.Node, pre bool) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c, pre)
+	}
+}
+
+// nextElement returns the next sibling element of n
+func nextElement(n *html.Node) *html.Node {
+	for c := n.NextSibling; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			return c
+		}
+	}
+	return nil
+}
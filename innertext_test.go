@@ -0,0 +1,56 @@
+package gohtml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInnerText(t *testing.T) {
+	tests := []struct {
+		input, expect string
+	}{
+		{`<div><p>a</p><p>b</p></div>`, "a\n\nb"},
+		{`<div>  hello   <b> world </b> !<br>next   line </div>`, "hello world !\nnext line"},
+		{`<div><div>one</div>two<div>three</div></div>`, "one\ntwo\nthree"},
+		{`<div>a<script>x()</script><style>p{}</style><span hidden>b</span><span style="display: none">c</span>d</div>`, "ad"},
+		{"<div><pre>  keep\n   this</pre>after</div>", "  keep\n   this\nafter"},
+		{`<div><table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>2</td></tr></table></div>`, "a\tb\n1\t2"},
+		{`<div><ul><li>x</li><li>y</li></ul></div>`, "x\ny"},
+	}
+	for i, test := range tests {
+		doc, _ := Parse(strings.NewReader(test.input))
+		div := doc.QuerySelector("body > div")
+		if actual := div.InnerText(); actual != test.expect {
+			t.Errorf("\n%d: got : %q, want: %q\n", i, actual, test.expect)
+		}
+	}
+
+	doc, _ := Parse(strings.NewReader(`<div hidden>a<p>b</p></div>`))
+	div := doc.QuerySelector("div")
+	if actual := div.InnerText(); actual != "ab" {
+		t.Errorf("\ngot : %q, want: %q\n", actual, "ab")
+	}
+
+	div.InnerText("x\ny")
+	if actual, expect := div.InnerHTML(), "x<br/>y"; actual != expect {
+		t.Errorf("\ngot : %q, want: %q\n", actual, expect)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	s := `<html><body><h1>Title</h1><p>Read the <a href="/doc">documentation</a> or the
+<a href="https://example.com/faq">frequently asked questions</a> before posting.</p></body></html>`
+
+	expect := "Title\n\n" +
+		"Read the [1]documentation or the\n" +
+		"[2]frequently asked questions before\n" +
+		"posting.\n\n" +
+		"References\n\n" +
+		"1. /doc\n" +
+		"2. https://example.com/faq\n"
+
+	doc, _ := Parse(strings.NewReader(s))
+	if actual := doc.PlainText(&PlainTextOptions{Width: 36, LinkFootnotes: true}); actual != expect {
+		t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, expect)
+	}
+}
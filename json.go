@@ -0,0 +1,334 @@
+package gohtml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// jsonNode is the tree schema of the JSON representation
+type jsonNode struct {
+	Type       string      `json:"type"`
+	Tag        string      `json:"tag,omitempty"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Attributes []jsonAttr  `json:"attributes,omitempty"`
+	Text       string      `json:"text,omitempty"`
+	Children   []*jsonNode `json:"children,omitempty"`
+}
+
+type jsonAttr struct {
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+}
+
+var nodeTypes = map[html.NodeType]string{
+	html.DocumentNode: "document",
+	html.ElementNode:  "element",
+	html.TextNode:     "text",
+	html.CommentNode:  "comment",
+	html.DoctypeNode:  "doctype",
+	html.RawNode:      "raw",
+}
+
+// MarshalJSON returns the element and the descendants as JSON, it implements json.Marshaler.
+// each node is an object that has "type" of "document", "element", "text",
+// "comment", "doctype" or "raw". an element has "tag", "namespace",
+// "attributes" in order and "children", and others have "text".
+// a doctype has the name as "tag" and the identifiers as "attributes"
+func (e Element) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e.Node)
+}
+
+// UnmarshalJSON rebuilds the element from JSON of MarshalJSON
+// or CompactJSON, it implements json.Unmarshaler
+func (e *Element) UnmarshalJSON(b []byte) error {
+	n, err := unmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	e.Node = n
+	return nil
+}
+
+// MarshalJSON returns the whole document as JSON, see Element.MarshalJSON
+func (d Document) MarshalJSON() ([]byte, error) {
+	return marshalJSON(d.Node)
+}
+
+// UnmarshalJSON rebuilds the document from JSON of MarshalJSON or CompactJSON
+func (d *Document) UnmarshalJSON(b []byte) error {
+	n, err := unmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	d.Node = n
+	return nil
+}
+
+// CompactJSON returns the element as the compact JSON. a text is a string,
+// an element is an array of the tag, the flat array of attribute keys and
+// values and the children like ["p",["id","a"],"text"]. the namespace is
+// separated from the tag or the key by a space like "svg circle".
+// the other nodes are arrays start with "#comment", "#doctype",
+// "#document" or "#raw"
+func (e Element) CompactJSON() ([]byte, error) {
+	return compactJSON(e.Node)
+}
+
+// CompactJSON returns the whole document as the compact JSON
+func (d Document) CompactJSON() ([]byte, error) {
+	return compactJSON(d.Node)
+}
+
+// FromJSON rebuilds a tree from JSON of MarshalJSON or CompactJSON
+func FromJSON(b []byte) (*Element, error) {
+	n, err := unmarshalJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	return &Element{n}, nil
+}
+
+func marshalJSON(n *html.Node) ([]byte, error) {
+	j, err := toJSONNode(n)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(j)
+}
+
+func toJSONNode(n *html.Node) (*jsonNode, error) {
+	typ, ok := nodeTypes[n.Type]
+	if !ok {
+		return nil, fmt.Errorf("gohtml: cannot marshal node type %d", n.Type)
+	}
+	j := &jsonNode{Type: typ}
+	switch n.Type {
+	case html.ElementNode, html.DoctypeNode:
+		j.Tag = n.Data
+		j.Namespace = n.Namespace
+		for _, a := range n.Attr {
+			j.Attributes = append(j.Attributes, jsonAttr{a.Namespace, a.Key, a.Val})
+		}
+	case html.TextNode, html.CommentNode, html.RawNode:
+		j.Text = n.Data
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		child, err := toJSONNode(c)
+		if err != nil {
+			return nil, err
+		}
+		j.Children = append(j.Children, child)
+	}
+	return j, nil
+}
+
+func unmarshalJSON(b []byte) (*html.Node, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] != '{' {
+		var v interface{}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		return fromCompact(v)
+	}
+
+	var j jsonNode
+	if err := json.Unmarshal(b, &j); err != nil {
+		return nil, err
+	}
+	return fromJSONNode(&j)
+}
+
+func fromJSONNode(j *jsonNode) (*html.Node, error) {
+	n := &html.Node{}
+	switch j.Type {
+	case "document":
+		n.Type = html.DocumentNode
+	case "element", "doctype":
+		if j.Tag == "" && j.Type == "element" {
+			return nil, fmt.Errorf("gohtml: element has no tag")
+		}
+		n.Type = html.ElementNode
+		if j.Type == "doctype" {
+			n.Type = html.DoctypeNode
+		} else {
+			n.DataAtom = atom.Lookup([]byte(strings.ToLower(j.Tag)))
+		}
+		n.Data = j.Tag
+		n.Namespace = j.Namespace
+		for _, a := range j.Attributes {
+			n.Attr = append(n.Attr, html.Attribute{Namespace: a.Namespace, Key: a.Key, Val: a.Value})
+		}
+	case "text", "comment", "raw":
+		n.Type = map[string]html.NodeType{"text": html.TextNode, "comment": html.CommentNode, "raw": html.RawNode}[j.Type]
+		n.Data = j.Text
+	default:
+		return nil, fmt.Errorf("gohtml: unknown node type %q", j.Type)
+	}
+
+	if len(j.Children) > 0 && n.Type != html.DocumentNode && n.Type != html.ElementNode {
+		return nil, fmt.Errorf("gohtml: %s node cannot have children", j.Type)
+	}
+	for _, c := range j.Children {
+		if c == nil {
+			return nil, fmt.Errorf("gohtml: null child node")
+		}
+		child, err := fromJSONNode(c)
+		if err != nil {
+			return nil, err
+		}
+		n.AppendChild(child)
+	}
+	return n, nil
+}
+
+func compactJSON(n *html.Node) ([]byte, error) {
+	v, err := toCompact(n)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// qualified returns the name prefixed with the namespace and a space
+func qualified(namespace, name string) string {
+	if namespace != "" {
+		return namespace + " " + name
+	}
+	return name
+}
+
+func toCompact(n *html.Node) (interface{}, error) {
+	var v []interface{}
+	switch n.Type {
+	case html.TextNode:
+		return n.Data, nil
+	case html.CommentNode:
+		return []interface{}{"#comment", n.Data}, nil
+	case html.RawNode:
+		return []interface{}{"#raw", n.Data}, nil
+	case html.DocumentNode:
+		v = []interface{}{"#document"}
+	case html.ElementNode, html.DoctypeNode:
+		attrs := []string{}
+		for _, a := range n.Attr {
+			attrs = append(attrs, qualified(a.Namespace, a.Key), a.Val)
+		}
+		if n.Type == html.DoctypeNode {
+			return []interface{}{"#doctype", n.Data, attrs}, nil
+		}
+		v = []interface{}{qualified(n.Namespace, n.Data), attrs}
+	default:
+		return nil, fmt.Errorf("gohtml: cannot marshal node type %d", n.Type)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		child, err := toCompact(c)
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, child)
+	}
+	return v, nil
+}
+
+// splitQualified splits the namespace and the name
+func splitQualified(s string) (namespace, name string) {
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return "", s
+}
+
+func compactAttrs(v interface{}) ([]html.Attribute, error) {
+	list, ok := v.([]interface{})
+	if !ok || len(list)%2 != 0 {
+		return nil, fmt.Errorf("gohtml: attributes must be an array of keys and values")
+	}
+	var attrs []html.Attribute
+	for i := 0; i < len(list); i += 2 {
+		k, ok1 := list[i].(string)
+		val, ok2 := list[i+1].(string)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("gohtml: attribute key and value must be strings")
+		}
+		ns, key := splitQualified(k)
+		attrs = append(attrs, html.Attribute{Namespace: ns, Key: key, Val: val})
+	}
+	return attrs, nil
+}
+
+func fromCompact(v interface{}) (*html.Node, error) {
+	if s, ok := v.(string); ok {
+		return &html.Node{Type: html.TextNode, Data: s}, nil
+	}
+	list, ok := v.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("gohtml: node must be a string or an array")
+	}
+	head, ok := list[0].(string)
+	if !ok || head == "" {
+		return nil, fmt.Errorf("gohtml: node array must start with a string")
+	}
+
+	n := &html.Node{}
+	children := list[1:]
+	switch head {
+	case "#comment", "#raw":
+		n.Type = html.CommentNode
+		if head == "#raw" {
+			n.Type = html.RawNode
+		}
+		if len(list) != 2 {
+			return nil, fmt.Errorf("gohtml: %s must have one string", head)
+		}
+		if n.Data, ok = list[1].(string); !ok {
+			return nil, fmt.Errorf("gohtml: %s must have one string", head)
+		}
+		return n, nil
+	case "#doctype":
+		n.Type = html.DoctypeNode
+		if len(list) != 3 {
+			return nil, fmt.Errorf("gohtml: #doctype must have the name and the attributes")
+		}
+		if n.Data, ok = list[1].(string); !ok {
+			return nil, fmt.Errorf("gohtml: #doctype name must be a string")
+		}
+		attrs, err := compactAttrs(list[2])
+		if err != nil {
+			return nil, err
+		}
+		n.Attr = attrs
+		return n, nil
+	case "#document":
+		n.Type = html.DocumentNode
+	default:
+		if len(list) < 2 {
+			return nil, fmt.Errorf("gohtml: element %q has no attributes", head)
+		}
+		attrs, err := compactAttrs(list[1])
+		if err != nil {
+			return nil, err
+		}
+		n.Type = html.ElementNode
+		n.Namespace, n.Data = splitQualified(head)
+		n.DataAtom = atom.Lookup([]byte(strings.ToLower(n.Data)))
+		n.Attr = attrs
+		children = list[2:]
+	}
+
+	for _, c := range children {
+		child, err := fromCompact(c)
+		if err != nil {
+			return nil, err
+		}
+		n.AppendChild(child)
+	}
+	return n, nil
+}
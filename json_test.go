@@ -0,0 +1,92 @@
+package gohtml
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const test_json = `<!DOCTYPE html PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd"><html><head><title>T</title></head><body><!-- c --><p id="a" class="b">text &amp; more</p><svg viewBox="0 0 1 1"><use xlink:href="#x"></use></svg><p></p></body></html>`
+
+// equalTree returns true if the trees have the same nodes
+func equalTree(a, b *html.Node) bool {
+	if a.Type != b.Type || a.Data != b.Data || a.DataAtom != b.DataAtom ||
+		a.Namespace != b.Namespace || len(a.Attr) != len(b.Attr) {
+		return false
+	}
+	for i := range a.Attr {
+		if a.Attr[i] != b.Attr[i] {
+			return false
+		}
+	}
+	ac, bc := a.FirstChild, b.FirstChild
+	for ; ac != nil && bc != nil; ac, bc = ac.NextSibling, bc.NextSibling {
+		if !equalTree(ac, bc) {
+			return false
+		}
+	}
+	return ac == nil && bc == nil
+}
+
+func TestJSON(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(test_json))
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Document
+	if err := json.Unmarshal(b, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !equalTree(doc.Node, again.Node) {
+		t.Errorf("\nround trip is not same: %s\n", b)
+	}
+
+	p := doc.QuerySelector("p")
+	b, _ = json.Marshal(p)
+	expect := `{"type":"element","tag":"p","attributes":[{"key":"id","value":"a"},{"key":"class","value":"b"}],"children":[{"type":"text","text":"text \u0026 more"}]}`
+	if actual := string(b); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+}
+
+func TestCompactJSON(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(test_json))
+
+	b, err := doc.CompactJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := FromJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalTree(doc.Node, e.Node) {
+		t.Errorf("\nround trip is not same: %s\n", b)
+	}
+
+	b, _ = doc.QuerySelector("svg").CompactJSON()
+	expect := `["svg svg",["viewBox","0 0 1 1"],["svg use",["xlink href","#x"]]]`
+	if actual := string(b); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+}
+
+func TestFromJSONError(t *testing.T) {
+	for _, s := range []string{
+		`{"type":"unknown"}`,
+		`{"type":"element"}`,
+		`{"type":"text","text":"a","children":[{"type":"text"}]}`,
+		`["p"]`,
+		`["p",["id"]]`,
+		`["#comment",1]`,
+		`1`,
+	} {
+		if _, err := FromJSON([]byte(s)); err == nil {
+			t.Errorf("\n%s: expected an error\n", s)
+		}
+	}
+}
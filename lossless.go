@@ -0,0 +1,473 @@
+package gohtml
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SourceHTML returns the outer HTML of the element. if the document was
+// parsed by ParseWithPositions, the nodes that have not been changed
+// are written as is in the original source text and only the changed
+// parts are serialized again, so an edit makes a minimal difference.
+// if the source text would be parsed into another tree, e.g. an edit
+// around misnested tags, the element is serialized as OuterHTML
+func (e Element) SourceHTML() string {
+	var buf bytes.Buffer
+	writeSource(&buf, e.Node)
+	return buf.String()
+}
+
+// SourceHTML returns the whole document in the same way as Element.SourceHTML
+func (d Document) SourceHTML() string {
+	var buf bytes.Buffer
+	writeSource(&buf, d.Node)
+	return buf.String()
+}
+
+// WriteSource writes the whole document in the same way as Element.SourceHTML
+func (d Document) WriteSource(w io.Writer) error {
+	var buf bytes.Buffer
+	if err := writeSource(&buf, d.Node); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func writeSource(buf *bytes.Buffer, n *html.Node) error {
+	m := sourceOf(n)
+	if m == nil {
+		return html.Render(buf, n)
+	}
+	m.mu.Lock()
+	w := &sourceWriter{sourceMap: m, newlineAt: -1}
+	err := w.write(n)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// the parser may build another tree from the source text,
+	// e.g. the context of a misnested tag was changed
+	out := w.bytes()
+	if !parsesTo(out, n) {
+		return html.Render(buf, n)
+	}
+	_, err = buf.Write(out)
+	return err
+}
+
+// parsesTo returns true if src is parsed into the same tree as n, or
+// the same tree as the serialized n when the tree can not be made by
+// the parser, e.g. a text in <head>. an element is parsed in the
+// context of its parent
+func parsesTo(src []byte, n *html.Node) bool {
+	var want bytes.Buffer
+	if err := html.Render(&want, n); err != nil {
+		// can not be compared
+		return true
+	}
+	got, ok := parseRender(src, n)
+	if !ok {
+		return false
+	}
+	if bytes.Equal(got, want.Bytes()) {
+		return true
+	}
+	again, ok := parseRender(want.Bytes(), n)
+	return ok && bytes.Equal(got, again)
+}
+
+// parseRender parses src in the place of n and renders the result
+func parseRender(src []byte, n *html.Node) ([]byte, bool) {
+	var buf bytes.Buffer
+	if n.Type == html.DocumentNode {
+		doc, err := html.Parse(bytes.NewReader(src))
+		if err != nil {
+			return nil, false
+		}
+		html.Render(&buf, doc)
+		return buf.Bytes(), true
+	}
+
+	var context *html.Node
+	if n.Parent != nil && n.Parent.Type == html.ElementNode {
+		context = n.Parent
+	}
+	nodes, err := html.ParseFragment(bytes.NewReader(src), context)
+	if err != nil {
+		return nil, false
+	}
+	for _, c := range nodes {
+		html.Render(&buf, c)
+	}
+	return buf.Bytes(), true
+}
+
+// measure records the start offsets of the parsed nodes
+// and finds out which node can be written as its source text
+func (m *sourceMap) measure(root *html.Node) {
+	for n, s := range m.nodes {
+		if !s.implied && n != root {
+			m.starts = append(m.starts, s.start)
+		}
+	}
+	sort.Ints(m.starts)
+	m.measureNode(root)
+}
+
+// measureNode returns the number of parsed descendants of n
+// and the range these are in
+func (m *sourceMap) measureNode(n *html.Node) (count, lo, hi int) {
+	lo, hi = -1, -1
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		cc, clo, chi := m.measureNode(c)
+		if s := m.nodes[c]; s != nil && !s.implied {
+			cc++
+			clo, chi = s.start, s.end
+		}
+		count += cc
+		if clo >= 0 && (lo < 0 || clo < lo) {
+			lo = clo
+		}
+		if chi > hi {
+			hi = chi
+		}
+	}
+
+	s := m.nodes[n]
+	if s == nil || s.implied {
+		return count, lo, hi
+	}
+	end := s.end
+	if s.endTag >= 0 {
+		end = s.endTag
+	}
+	s.exact = m.countStarts(s.tagEnd, end) == count &&
+		(count == 0 || (lo >= s.tagEnd && hi <= end))
+	return count, lo, hi
+}
+
+// countStarts returns the number of parsed nodes started in [from, to)
+func (m *sourceMap) countStarts(from, to int) int {
+	if to <= from {
+		return 0
+	}
+	i := sort.SearchInts(m.starts, from)
+	j := sort.SearchInts(m.starts, to)
+	return j - i
+}
+
+// clean returns true if n and its descendants have not been changed
+func (m *sourceMap) clean(n *html.Node) bool {
+	s := m.nodes[n]
+	if s == nil || s.modified || s.attrsModified {
+		return false
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if !m.clean(c) {
+			return false
+		}
+	}
+	return true
+}
+
+var rawTextElements = map[string]bool{
+	"iframe": true, "noembed": true, "noframes": true, "noscript": true,
+	"plaintext": true, "script": true, "style": true, "xmp": true,
+}
+
+// newlineElements ignore a newline just after the start tag
+var newlineElements = map[string]bool{
+	"listing": true, "pre": true, "textarea": true,
+}
+
+// span is a range of the source
+type span struct {
+	start, end int
+}
+
+// sourceWriter writes the nodes as the source text. an end tag is
+// copied from the source where the element ends, but it is dropped
+// at last if the same bytes are also copied as a part of the other
+// node, e.g. "</b>" in "<b><p>one</b>two</p>" is a part of <p>
+type sourceWriter struct {
+	*sourceMap
+	buf     bytes.Buffer
+	copied  []span
+	last    int // the max end of copied
+	endTags []endTagCopy
+	// newlineAt is the length of buf just after the start tag
+	// of the element that ignores a newline
+	newlineAt int
+}
+
+// endTagCopy is an end tag copied from src to out of the writer
+type endTagCopy struct {
+	src, out span
+}
+
+// copy writes the source between from and to
+func (w *sourceWriter) copy(from, to int) {
+	w.copied = append(w.copied, span{from, to})
+	if to > w.last {
+		w.last = to
+	}
+	w.buf.Write(w.src[from:to])
+}
+
+// copyGap writes the source between from and to that is not
+// copied yet. a text node may contain the tags the parser ignored
+func (w *sourceWriter) copyGap(from, to int) {
+	if from >= w.last {
+		w.copy(from, to)
+		return
+	}
+	for _, c := range w.copied {
+		if c.start < to && from < c.end {
+			if from < c.start {
+				w.copyGap(from, c.start)
+			}
+			if c.end < to {
+				w.copyGap(c.end, to)
+			}
+			return
+		}
+	}
+	w.copy(from, to)
+}
+
+// copyEndTag writes the end tag between from and to
+func (w *sourceWriter) copyEndTag(from, to int) {
+	start := w.buf.Len()
+	w.buf.Write(w.src[from:to])
+	w.endTags = append(w.endTags, endTagCopy{span{from, to}, span{start, w.buf.Len()}})
+}
+
+// bytes returns the written text without the end tags written twice
+func (w *sourceWriter) bytes() []byte {
+	out := w.buf.Bytes()
+	if len(w.endTags) == 0 {
+		return out
+	}
+
+	// merge the copied ranges to find the one an end tag is in
+	sort.Slice(w.copied, func(i, j int) bool {
+		return w.copied[i].start < w.copied[j].start
+	})
+	var merged []span
+	for _, c := range w.copied {
+		if k := len(merged) - 1; k >= 0 && c.start <= merged[k].end {
+			if c.end > merged[k].end {
+				merged[k].end = c.end
+			}
+			continue
+		}
+		merged = append(merged, c)
+	}
+
+	var b bytes.Buffer
+	last := 0
+	for _, e := range w.endTags {
+		i := sort.Search(len(merged), func(i int) bool {
+			return merged[i].start > e.src.start
+		}) - 1
+		if i >= 0 && e.src.end <= merged[i].end {
+			b.Write(out[last:e.out.start])
+			last = e.out.end
+		}
+	}
+	b.Write(out[last:])
+	return b.Bytes()
+}
+
+// writeNew writes n created or changed after parsing
+func (w *sourceWriter) writeNew(n *html.Node) error {
+	if n.Type != html.TextNode {
+		return html.Render(&w.buf, n)
+	}
+	p := n.Parent
+	if p != nil && p.Type == html.ElementNode && p.Namespace == "" {
+		if rawTextElements[p.Data] {
+			w.buf.WriteString(n.Data)
+			return nil
+		}
+		// the newline ignored by the parser
+		if w.buf.Len() == w.newlineAt && strings.HasPrefix(n.Data, "\n") {
+			w.buf.WriteByte('\n')
+		}
+	}
+	w.buf.WriteString(html.EscapeString(n.Data))
+	return nil
+}
+
+func (w *sourceWriter) write(n *html.Node) error {
+	s := w.nodes[n]
+	switch {
+	case s == nil:
+		return w.writeNew(n)
+	case s.implied:
+		// the tags implied by the parser will be implied again
+		if s.attrsModified && n.Type == html.ElementNode {
+			w.writeTag(n)
+		}
+		_, err := w.writeChildren(n, -1)
+		return err
+	case n.Type != html.ElementNode && n.Type != html.DocumentNode:
+		if s.modified {
+			return w.writeNew(n)
+		}
+		w.copy(s.start, s.end)
+		return nil
+	case s.exact && w.clean(n):
+		w.copy(s.start, s.end)
+		return nil
+	}
+
+	if n.Type == html.ElementNode {
+		w.writeStartTag(n, s)
+	}
+	cursor, err := w.writeChildren(n, s.tagEnd)
+	if err != nil {
+		return err
+	}
+	if n.Type == html.DocumentNode {
+		if cursor >= 0 && w.safeGap(cursor, len(w.src)) {
+			w.copyGap(cursor, len(w.src))
+		}
+		return nil
+	}
+	// the end tag may be written already before a displaced child
+	if s.endTag < 0 || cursor < s.end {
+		w.writeEndTag(n, s, cursor)
+	}
+	return nil
+}
+
+// writeChildren writes the child nodes of n. cursor is the offset
+// in the source just before the children, or -1 if unknown.
+// returns the cursor after the last child
+func (w *sourceWriter) writeChildren(n *html.Node, cursor int) (int, error) {
+	ps := w.nodes[n]
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		s := w.nodes[c]
+		if s == nil || s.implied {
+			if s != nil && !s.attrsModified {
+				var err error
+				if cursor, err = w.writeChildren(c, cursor); err != nil {
+					return cursor, err
+				}
+				continue
+			}
+			if err := w.write(c); err != nil {
+				return cursor, err
+			}
+			continue
+		}
+
+		// a child moved after the end tag by the parser,
+		// e.g. the white space after </body>
+		if ps != nil && !ps.implied && ps.endTag >= 0 && cursor < ps.end && s.start >= ps.end {
+			w.writeEndTag(n, ps, cursor)
+			cursor = ps.end
+		}
+
+		if cursor >= 0 && w.safeGap(cursor, s.start) {
+			w.copyGap(cursor, s.start)
+		}
+		if err := w.write(c); err != nil {
+			return cursor, err
+		}
+		cursor = s.end
+
+		// the end tag omitted in the source is needed if the following
+		// node is not the original one. it is written already if modified
+		if c.Type == html.ElementNode && s.endTag < 0 && !s.modified && !voidElements[c.Data] && c.NextSibling != nil {
+			ns := w.nodes[c.NextSibling]
+			if ns == nil || ns.implied || !w.safeGap(s.end, ns.start) {
+				w.buf.WriteString("</" + c.Data + ">")
+			}
+		}
+	}
+	return cursor, nil
+}
+
+// safeGap returns true if the source between from and to
+// is not a part of any node
+func (m *sourceMap) safeGap(from, to int) bool {
+	if from > to {
+		return false
+	}
+	return m.countStarts(from, to) == 0
+}
+
+func (w *sourceWriter) writeStartTag(n *html.Node, s *sourceNode) {
+	defer w.markNewline(n)
+	if !s.attrsModified {
+		w.copy(s.start, s.tagEnd)
+		return
+	}
+
+	// keep the raw text of the attributes which are not changed
+	w.copy(s.start, s.start+tagNameEnd(w.src[s.start:s.tagEnd]))
+	for _, a := range n.Attr {
+		raw := false
+		for _, sa := range s.attrs {
+			if sa.Namespace == a.Namespace && sa.Key == a.Key && sa.Val == a.Val {
+				lead := sa.start
+				for lead > s.start && isSpace(w.src[lead-1]) {
+					lead--
+				}
+				w.copy(lead, sa.end)
+				raw = true
+				break
+			}
+		}
+		if !raw {
+			writeAttr(&w.buf, a)
+		}
+	}
+	w.copy(s.tagTail, s.tagEnd)
+}
+
+// writeTag writes the start tag of n created by the parser
+func (w *sourceWriter) writeTag(n *html.Node) {
+	w.buf.WriteString("<" + n.Data)
+	for _, a := range n.Attr {
+		writeAttr(&w.buf, a)
+	}
+	w.buf.WriteByte('>')
+	w.markNewline(n)
+}
+
+func (w *sourceWriter) markNewline(n *html.Node) {
+	if n.Namespace == "" && newlineElements[n.Data] {
+		w.newlineAt = w.buf.Len()
+	}
+}
+
+func writeAttr(buf *bytes.Buffer, a html.Attribute) {
+	key := a.Key
+	if a.Namespace != "" {
+		key = a.Namespace + ":" + a.Key
+	}
+	buf.WriteString(" " + key + `="` + html.EscapeString(a.Val) + `"`)
+}
+
+// writeEndTag writes the end tag of n with the source before it
+func (w *sourceWriter) writeEndTag(n *html.Node, s *sourceNode, cursor int) {
+	if s.endTag >= 0 {
+		if cursor >= 0 && w.safeGap(cursor, s.endTag) {
+			w.copyGap(cursor, s.endTag)
+		}
+		w.copyEndTag(s.endTag, s.end)
+		return
+	}
+	if s.modified && !voidElements[n.Data] {
+		w.buf.WriteString("</" + n.Data + ">")
+	}
+}
@@ -0,0 +1,218 @@
+package gohtml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+var test_lossless = []string{
+	test_source,
+	`<P CLASS=foo>unquoted &amp; <B>upper</B></P>`,
+	`<ul><li>one<li>two</ul>`,
+	`<table><tr><td>1<td>2</table>`,
+	`<!doctype html><title>a &lt; b</title><script>if (a < b) {}</script>`,
+	"<div>\r\n\tcrlf\r\n</div>\n\n",
+	`<p><b>x<p>y</b>z`,
+	`<svg viewBox="0 0 1 1"><path d="M0 0"/></svg>`,
+	"<textarea>\n&lt;x</textarea>",
+	"<pre>\r\n\nx &amp; y</pre>",
+	"<!DOCTYPE html>\n<html><body><p>x</p></body></html>\n",
+	`<b><p>one</b>two</p>`,
+	`<p>a &lt;b&gt; c<br>d&nbsp;e</p>`,
+	`<table><tr><td>a</td></tr>x</table>`,
+}
+
+// parseAgain returns the tree parsed from s as HTML
+func parseAgain(s string) string {
+	doc, _ := html.Parse(strings.NewReader(s))
+	var buf bytes.Buffer
+	html.Render(&buf, doc)
+	return buf.String()
+}
+
+func TestSourceHTMLUnchanged(t *testing.T) {
+	for _, s := range test_lossless {
+		doc, err := ParseWithPositions(strings.NewReader(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := doc.SourceHTML()
+		if actual != s {
+			t.Errorf("\ngot : %q\nwant: %q\n", actual, s)
+		}
+		var tree bytes.Buffer
+		html.Render(&tree, doc.Node)
+		if again := parseAgain(actual); again != tree.String() {
+			t.Errorf("\n%q: parsed again: %q\nwant: %q\n", s, again, tree.String())
+		}
+		doc.Release()
+	}
+}
+
+func TestSourceHTMLChanged(t *testing.T) {
+	tests := []struct {
+		src    string
+		edit   func(d *Document)
+		expect string
+	}{
+		{
+			`<div  id=a CLASS='x'   title="t">text</div>`,
+			func(d *Document) { d.QuerySelector("div").SetAttribute("class", "y") },
+			`<div  id=a class="y"   title="t">text</div>`,
+		},
+		{
+			"<ul>\n  <li>one</li>\n  <li>two</li>\n</ul>",
+			func(d *Document) {
+				li := d.QuerySelectorAll("li").Get(1)
+				li.ParentElement().RemoveChild(li)
+			},
+			"<ul>\n  <li>one</li>\n  \n</ul>",
+		},
+		{
+			"<ul>\n  <li>one</li>\n</ul>",
+			func(d *Document) {
+				li := CreateElement("li")
+				li.AppendChild(CreateTextNode("a & b"))
+				d.QuerySelector("ul").AppendChild(li)
+			},
+			"<ul>\n  <li>one</li>\n<li>a &amp; b</li></ul>",
+		},
+		{
+			`<ul><li>one<li>two</ul>`,
+			func(d *Document) {
+				d.QuerySelector("li").AppendChild(CreateElement("div"))
+			},
+			`<ul><li>one<div></div></li><li>two</ul>`,
+		},
+		{
+			"<!DOCTYPE html>\n<html><body><p>x</p></body></html>\n",
+			func(d *Document) { d.QuerySelector("p").SetAttribute("class", "a") },
+			"<!DOCTYPE html>\n<html><body><p class=\"a\">x</p></body></html>\n",
+		},
+		{
+			`<b><p>one</b>two</p>`,
+			func(d *Document) { d.QuerySelector("p").SetAttribute("class", "a") },
+			`<b><p class="a">one</b>two</p>`,
+		},
+		{
+			"<textarea>\n&lt;x</textarea><pre>\n\ny</pre>",
+			func(d *Document) {
+				d.QuerySelector("textarea").SetAttribute("rows", "2")
+				d.QuerySelector("pre").AppendChild(CreateTextNode("z"))
+			},
+			"<textarea rows=\"2\">\n&lt;x</textarea><pre>\n\nyz</pre>",
+		},
+		{
+			"<pre>\nx</pre>",
+			func(d *Document) { d.QuerySelector("pre").TextContent("\ny") },
+			"<pre>\n\ny</pre>",
+		},
+		{
+			"<body><p>a</p></body>\n",
+			func(d *Document) {
+				d.QuerySelector("p").TextContent("b")
+			},
+			"<body><p>b</p></body>\n",
+		},
+	}
+
+	for i, test := range tests {
+		doc, err := ParseWithPositions(strings.NewReader(test.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.edit(doc)
+
+		actual := doc.SourceHTML()
+		if actual != test.expect {
+			t.Errorf("\n%d: got : %q\nwant: %q\n", i, actual, test.expect)
+		}
+
+		// must be the same tree after parsing again
+		again, _ := Parse(strings.NewReader(actual))
+		want, _ := Parse(strings.NewReader(doc.DocumentElement().OuterHTML()))
+		if a, b := again.DocumentElement().OuterHTML(), want.DocumentElement().OuterHTML(); a != b {
+			t.Errorf("\n%d: parsed again: %q\nwant: %q\n", i, a, b)
+		}
+		doc.Release()
+	}
+}
+
+func TestSourceHTMLMutations(t *testing.T) {
+	edits := []func(e *Element) bool{
+		func(e *Element) bool {
+			if e.Node.Type != html.ElementNode {
+				return false
+			}
+			e.SetAttribute("data-x", "1")
+			return true
+		},
+		func(e *Element) bool {
+			if e.Node.Type != html.TextNode {
+				return false
+			}
+			e.NodeValue("a < b")
+			return true
+		},
+		func(e *Element) bool {
+			if e.Node.Type != html.ElementNode || voidElements[e.Node.Data] {
+				return false
+			}
+			e.AppendChild(CreateTextNode("\nz"))
+			return true
+		},
+		func(e *Element) bool {
+			if e.Node.Parent == nil || e.Node.Parent.Type == html.DocumentNode {
+				return false
+			}
+			e.Remove()
+			return true
+		},
+	}
+
+	var nodes []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		nodes = append(nodes, n)
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	for _, s := range test_lossless {
+		doc, _ := ParseWithPositions(strings.NewReader(s))
+		nodes = nodes[:0]
+		walk(doc.Node)
+		count := len(nodes)
+		doc.Release()
+
+		for i := 0; i < count; i++ {
+			for j, edit := range edits {
+				doc, _ := ParseWithPositions(strings.NewReader(s))
+				nodes = nodes[:0]
+				walk(doc.Node)
+				if edit(&Element{nodes[i]}) {
+					// must be the same tree after parsing again
+					var tree bytes.Buffer
+					html.Render(&tree, doc.Node)
+					actual := doc.SourceHTML()
+					if a, b := parseAgain(actual), parseAgain(tree.String()); a != b {
+						t.Errorf("\n%q: edit %d of node %d\nparsed again: %q\nwant: %q\n", s, j, i, a, b)
+					}
+				}
+				doc.Release()
+			}
+		}
+	}
+}
+
+func TestSourceHTMLWithoutPositions(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<p class=a>x</p>`))
+	p := doc.QuerySelector("p")
+	if p.SourceHTML() != p.OuterHTML() {
+		t.Errorf("\ngot : %s, want: %s\n", p.SourceHTML(), p.OuterHTML())
+	}
+}
@@ -0,0 +1,585 @@
+package gohtml
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/saihon/gohtml/attr"
+	"github.com/saihon/gohtml/utils"
+)
+
+// MarkdownFlavor is the dialect of Markdown
+type MarkdownFlavor int
+
+const (
+	// CommonMark writes Markdown of the CommonMark spec.
+	// tables are written as HTML
+	CommonMark MarkdownFlavor = iota
+	// GFM writes GitHub Flavored Markdown, which has
+	// tables, strikethrough and task list items
+	GFM
+)
+
+// MarkdownOptions is the options for Markdown
+type MarkdownOptions struct {
+	// Flavor is the dialect of Markdown
+	Flavor MarkdownFlavor
+	// ReferenceLinks writes links as [text][1] and the
+	// link reference definitions at the end
+	ReferenceLinks bool
+	// Bullet is the marker of the unordered list items. "-" if empty
+	Bullet string
+}
+
+// Markdown returns the element converted to Markdown
+func (e Element) Markdown(opts *MarkdownOptions) string {
+	return markdown(e.Node, opts)
+}
+
+// Markdown returns the body of the document converted to Markdown
+func (d Document) Markdown(opts *MarkdownOptions) string {
+	if body := d.Body(); body != nil {
+		return markdown(body.Node, opts)
+	}
+	return markdown(d.Node, opts)
+}
+
+func markdown(n *html.Node, opts *MarkdownOptions) string {
+	m := &mdConverter{}
+	if opts != nil {
+		m.opts = *opts
+	}
+	if m.opts.Bullet == "" {
+		m.opts.Bullet = "-"
+	}
+
+	var s string
+	if n.Type == html.ElementNode && isInline(n) && !isHTML(n, preserveElements) {
+		s = m.paragraph(n, n.NextSibling)
+	} else if n.Type == html.ElementNode {
+		s = m.element(n)
+	} else if n.Type == html.DocumentNode {
+		s = m.block(n)
+	}
+
+	if len(m.refs) > 0 {
+		var b strings.Builder
+		for i, r := range m.refs {
+			b.WriteString("[" + strconv.Itoa(i+1) + "]: " + r + "\n")
+		}
+		s = joinBlocks([]string{s, b.String()}, "\n\n")
+	}
+	if s != "" {
+		s = strings.TrimRight(s, "\n") + "\n"
+	}
+	return s
+}
+
+type mdConverter struct {
+	opts MarkdownOptions
+	refs []string
+}
+
+// mdSkip are the elements which have no Markdown content
+var mdSkip = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true,
+	"noscript": true, "iframe": true, "object": true, "embed": true,
+	"select": true, "textarea": true, "button": true, "input": true,
+}
+
+// joinBlocks joins the non-empty blocks with sep
+func joinBlocks(blocks []string, sep string) string {
+	var kept []string
+	for _, b := range blocks {
+		if strings.TrimSpace(b) != "" {
+			kept = append(kept, strings.TrimRight(b, "\n"))
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+// indentLines prefixes each line of s except the first with prefix
+func indentLines(s, first, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + lines[i]
+		case lines[i] != "":
+			lines[i] = prefix + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// blocks converts the child nodes of n into the blocks.
+// the inline nodes in a row are one paragraph
+func (m *mdConverter) blocks(n *html.Node) []string {
+	var (
+		blocks []string
+		first  *html.Node
+	)
+	flush := func(next *html.Node) {
+		if first != nil {
+			blocks = append(blocks, m.paragraph(first, next))
+			first = nil
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode || c.Type == html.CommentNode || (c.Type == html.ElementNode && isHTML(c, inlineElements)) {
+			if first == nil {
+				first = c
+			}
+			continue
+		}
+		flush(c)
+		if c.Type == html.ElementNode {
+			blocks = append(blocks, m.element(c))
+		}
+	}
+	flush(nil)
+	return blocks
+}
+
+func (m *mdConverter) block(n *html.Node) string {
+	return joinBlocks(m.blocks(n), "\n\n")
+}
+
+// element converts the block element
+func (m *mdConverter) element(n *html.Node) string {
+	if n.Namespace != "" || mdSkip[n.Data] {
+		return ""
+	}
+
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		s := strings.ReplaceAll(m.paragraph(n.FirstChild, nil), "\\\n", " ")
+		if s == "" {
+			return ""
+		}
+		return strings.Repeat("#", int(n.Data[1]-'0')) + " " + s
+	case "p":
+		return m.paragraph(n.FirstChild, nil)
+	case "hr":
+		return "---"
+	case "pre", "listing", "plaintext", "xmp":
+		return m.codeBlock(n)
+	case "blockquote":
+		s := m.block(n)
+		lines := strings.Split(s, "\n")
+		for i, l := range lines {
+			if l == "" {
+				lines[i] = ">"
+			} else {
+				lines[i] = "> " + l
+			}
+		}
+		return strings.Join(lines, "\n")
+	case "ul", "ol", "menu":
+		return m.list(n)
+	case "dl":
+		return m.definitions(n)
+	case "table":
+		if m.opts.Flavor == GFM {
+			return m.table(n)
+		}
+		return renderString(n)
+	}
+	return m.block(n)
+}
+
+// codeBlock converts <pre> into the fenced code block
+func (m *mdConverter) codeBlock(n *html.Node) string {
+	lang := language(n)
+	if c := n.FirstChild; c != nil && c.NextSibling == nil && c.Type == html.ElementNode && c.Data == "code" {
+		if l := language(c); l != "" {
+			lang = l
+		}
+	}
+
+	code := strings.TrimSuffix(utils.Text(n), "\n")
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + code + "\n" + fence
+}
+
+// language returns the language of the code in the class like "language-go"
+func language(n *html.Node) string {
+	for _, c := range strings.Fields(attr.Get(n, "class")) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(c, prefix) {
+				return c[len(prefix):]
+			}
+		}
+	}
+	return ""
+}
+
+// list converts <ul> or <ol>. the list is loose if an item has paragraphs
+func (m *mdConverter) list(n *html.Node) string {
+	loose := false
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		for c := li.FirstChild; c != nil; c = c.NextSibling {
+			if isHTML(c, map[string]bool{"p": true}) {
+				loose = true
+			}
+		}
+	}
+	sep := "\n"
+	if loose {
+		sep = "\n\n"
+	}
+
+	number := 1
+	if s, err := strconv.Atoi(attr.Get(n, "start")); err == nil {
+		number = s
+	}
+
+	var items []string
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode {
+			continue
+		}
+		if li.Data != "li" {
+			items = append(items, m.element(li))
+			continue
+		}
+
+		marker := m.opts.Bullet + " "
+		if n.Data == "ol" {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		indent := strings.Repeat(" ", len(marker))
+		if m.opts.Flavor == GFM {
+			marker += m.task(li)
+		}
+		content := joinBlocks(m.blocks(li), sep)
+		items = append(items, indentLines(content, marker, indent))
+	}
+	return strings.Join(items, sep)
+}
+
+// task returns the task list item marker if the item starts with a checkbox
+func (m *mdConverter) task(li *html.Node) string {
+	c := li.FirstChild
+	for c != nil && c.Type == html.TextNode && strings.TrimSpace(c.Data) == "" {
+		c = c.NextSibling
+	}
+	if c == nil || !isHTML(c, map[string]bool{"input": true}) || !strings.EqualFold(attr.Get(c, "type"), "checkbox") {
+		return ""
+	}
+	if attr.Has(c, "checked") {
+		return "[x] "
+	}
+	return "[ ] "
+}
+
+// definitions converts <dl>. the terms are bold
+func (m *mdConverter) definitions(n *html.Node) string {
+	var blocks []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case isHTML(c, map[string]bool{"dt": true}):
+			if s := m.paragraph(c.FirstChild, nil); s != "" {
+				blocks = append(blocks, "**"+s+"**")
+			}
+		case c.Type == html.ElementNode:
+			blocks = append(blocks, m.element(c))
+		}
+	}
+	return joinBlocks(blocks, "\n\n")
+}
+
+// table converts the table into the GFM table. the first row is the header
+func (m *mdConverter) table(n *html.Node) string {
+	var rows [][]*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case isHTML(c, map[string]bool{"thead": true, "tbody": true, "tfoot": true}):
+				walk(c)
+			case isHTML(c, map[string]bool{"tr": true}):
+				var row []*html.Node
+				for td := c.FirstChild; td != nil; td = td.NextSibling {
+					if isHTML(td, map[string]bool{"td": true, "th": true}) {
+						row = append(row, td)
+					}
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	cols := 0
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if cols == 0 {
+		return ""
+	}
+
+	line := func(row []*html.Node) string {
+		var b strings.Builder
+		b.WriteString("|")
+		for i := 0; i < cols; i++ {
+			cell := ""
+			if i < len(row) {
+				cell = m.paragraph(row[i].FirstChild, nil)
+				cell = strings.ReplaceAll(cell, "\\\n", "<br>")
+				cell = strings.ReplaceAll(cell, "\n", " ")
+				cell = strings.ReplaceAll(cell, "|", `\|`)
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		return b.String()
+	}
+
+	lines := []string{line(rows[0])}
+	var b strings.Builder
+	b.WriteString("|")
+	for i := 0; i < cols; i++ {
+		align := ""
+		if i < len(rows[0]) {
+			align = strings.ToLower(attr.Get(rows[0][i], "align"))
+			style := strings.ReplaceAll(strings.ToLower(attr.Get(rows[0][i], "style")), " ", "")
+			if j := strings.Index(style, "text-align:"); j >= 0 {
+				align = strings.TrimRight(style[j+len("text-align:"):], ";")
+				if k := strings.Index(align, ";"); k >= 0 {
+					align = align[:k]
+				}
+			}
+		}
+		switch align {
+		case "left":
+			b.WriteString(" :--- |")
+		case "right":
+			b.WriteString(" ---: |")
+		case "center":
+			b.WriteString(" :---: |")
+		default:
+			b.WriteString(" --- |")
+		}
+	}
+	lines = append(lines, b.String())
+	for _, row := range rows[1:] {
+		lines = append(lines, line(row))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mdBreak marks the hard line break until the white space is collapsed.
+// the parser never makes a text that has NUL
+const mdBreak = "\x00"
+
+// paragraph converts the sibling nodes from first until last into
+// the inline content. the white space is collapsed and trimmed
+func (m *mdConverter) paragraph(first, last *html.Node) string {
+	var b strings.Builder
+	for c := first; c != last; c = c.NextSibling {
+		b.WriteString(m.inline(c))
+	}
+
+	lines := strings.Split(collapseSpace(b.String()), mdBreak)
+	for i := range lines {
+		lines[i] = escapeLineStart(strings.Trim(lines[i], " "))
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\\\n")
+}
+
+// inline converts the inline node. white space is not collapsed yet
+func (m *mdConverter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return m.escape(n.Data)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if n.Namespace != "" || mdSkip[n.Data] {
+		return ""
+	}
+
+	switch n.Data {
+	case "br":
+		return mdBreak
+	case "em", "i", "cite", "dfn", "var":
+		return emphasis(m.children(n), "*")
+	case "strong", "b":
+		return emphasis(m.children(n), "**")
+	case "del", "s", "strike":
+		if m.opts.Flavor == GFM {
+			return emphasis(m.children(n), "~~")
+		}
+	case "code", "kbd", "samp", "tt":
+		return codeSpan(utils.Text(n))
+	case "a":
+		return m.link(n)
+	case "img":
+		return m.image(n)
+	case "pre":
+		return " " + codeSpan(utils.Text(n)) + " "
+	}
+
+	s := m.children(n)
+	if !isHTML(n, inlineElements) {
+		// a block in an inline element
+		s = " " + s + " "
+	}
+	return s
+}
+
+func (m *mdConverter) children(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(m.inline(c))
+	}
+	return b.String()
+}
+
+// emphasis wraps s with the marker. the white space around s is left outside
+func emphasis(s, marker string) string {
+	core := strings.TrimFunc(s, isSpaceRune)
+	if core == "" {
+		return s
+	}
+	i := strings.Index(s, core)
+	return s[:i] + marker + core + marker + s[i+len(core):]
+}
+
+// codeSpan returns the code span that the backticks
+// are longer than any backtick run in s
+func codeSpan(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return ""
+	}
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// link converts <a>. a link which text is the URL is an autolink
+func (m *mdConverter) link(n *html.Node) string {
+	text := m.children(n)
+	href, ok := attr.GetNode(n, "href")
+	if !ok {
+		return text
+	}
+	if strings.TrimSpace(text) == "" {
+		return text
+	}
+
+	dest, title := linkDestination(href.Val), attr.Get(n, "title")
+	if title == "" && strings.TrimSpace(utils.Text(n)) == href.Val &&
+		strings.Contains(href.Val, ":") && !strings.ContainsAny(href.Val, " <>") {
+		return "<" + href.Val + ">"
+	}
+	core := strings.TrimFunc(text, isSpaceRune)
+	i := strings.Index(text, core)
+	return text[:i] + m.target(core, dest, title) + text[i+len(core):]
+}
+
+// target returns the link destination following the link text
+func (m *mdConverter) target(text, dest, title string) string {
+	if title != "" {
+		dest += ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+	}
+	if !m.opts.ReferenceLinks {
+		return "[" + text + "](" + dest + ")"
+	}
+	for i, r := range m.refs {
+		if r == dest {
+			return "[" + text + "][" + strconv.Itoa(i+1) + "]"
+		}
+	}
+	m.refs = append(m.refs, dest)
+	return "[" + text + "][" + strconv.Itoa(len(m.refs)) + "]"
+}
+
+// image converts <img>
+func (m *mdConverter) image(n *html.Node) string {
+	src := attr.Get(n, "src")
+	if src == "" {
+		return ""
+	}
+	alt := m.escape(strings.Join(strings.Fields(attr.Get(n, "alt")), " "))
+	title := attr.Get(n, "title")
+	if title != "" {
+		return "![" + alt + "](" + linkDestination(src) + ` "` + strings.ReplaceAll(title, `"`, `\"`) + `")`
+	}
+	return "![" + alt + "](" + linkDestination(src) + ")"
+}
+
+// linkDestination returns the URL as the link destination.
+// it is enclosed in <> if it has spaces or parentheses
+func linkDestination(u string) string {
+	if u == "" {
+		return "<>"
+	}
+	if strings.ContainsAny(u, " ()<>") {
+		r := strings.NewReplacer("<", "%3C", ">", "%3E")
+		return "<" + r.Replace(u) + ">"
+	}
+	return u
+}
+
+// escape escapes the characters which have meaning in Markdown
+func (m *mdConverter) escape(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\', '`', '*', '_', '[', ']', '<', '>':
+			b.WriteByte('\\')
+		case '~':
+			if m.opts.Flavor == GFM {
+				b.WriteByte('\\')
+			}
+		case '&':
+			if i+1 < len(s) && (s[i+1] == '#' || ('a' <= s[i+1] && s[i+1] <= 'z') || ('A' <= s[i+1] && s[i+1] <= 'Z')) {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// escapeLineStart escapes the characters which start a block
+// such as a heading or a list item at the start of the line
+func escapeLineStart(s string) string {
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '#', '=', '+', '-', '>':
+		return "\\" + s
+	}
+	i := 0
+	for i < len(s) && i < 9 && '0' <= s[i] && s[i] <= '9' {
+		i++
+	}
+	if i > 0 && i < len(s) && (s[i] == '.' || s[i] == ')') {
+		return s[:i] + "\\" + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,84 @@
+package gohtml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	s := `<html><head><title>T</title></head><body>
+<h1>Title <small>sub</small></h1>
+<p>Some <em>emphasis</em>, <strong>strong </strong>and <code>a` + "`" + `b</code>.<br>
+Next line with <a href="https://example.com/a" title="A">a link</a> and <img src="i.png" alt="pic">.</p>
+<p>1. not a list * [x] _y_</p>
+<ul>
+  <li>one</li>
+  <li>two
+    <ol start="3"><li>three</li><li>four</li></ol>
+  </li>
+</ul>
+<blockquote><p>quote</p><p>second</p></blockquote>
+<pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}
+</code></pre>
+<script>ignored()</script>
+<hr>
+<p><a href="https://example.com">https://example.com</a></p>
+</body></html>`
+
+	expect := "# Title sub\n\n" +
+		"Some *emphasis*, **strong** and ``a`b``.\\\n" +
+		"Next line with [a link](https://example.com/a \"A\") and ![pic](i.png).\n\n" +
+		"1\\. not a list \\* \\[x\\] \\_y\\_\n\n" +
+		"- one\n" +
+		"- two\n" +
+		"  3. three\n" +
+		"  4. four\n\n" +
+		"> quote\n" +
+		">\n" +
+		"> second\n\n" +
+		"```go\n" +
+		"func main() {\n" +
+		"\tfmt.Println(\"hi\")\n" +
+		"}\n" +
+		"```\n\n" +
+		"---\n\n" +
+		"<https://example.com>\n"
+
+	doc, _ := Parse(strings.NewReader(s))
+	if actual := doc.Markdown(nil); actual != expect {
+		t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, expect)
+	}
+}
+
+func TestMarkdownGFM(t *testing.T) {
+	s := `<table><thead><tr><th>Name</th><th align="right">Count</th></tr></thead>
+<tbody><tr><td>a|b</td><td>1</td></tr><tr><td><del>old</del></td></tr></tbody></table>
+<ul><li><input type="checkbox" checked> done</li><li><input type="checkbox"> todo</li></ul>`
+
+	expect := "| Name | Count |\n" +
+		"| --- | ---: |\n" +
+		"| a\\|b | 1 |\n" +
+		"| ~~old~~ |  |\n\n" +
+		"- [x] done\n" +
+		"- [ ] todo\n"
+
+	doc, _ := Parse(strings.NewReader(s))
+	if actual := doc.Markdown(&MarkdownOptions{Flavor: GFM}); actual != expect {
+		t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, expect)
+	}
+}
+
+func TestMarkdownReferenceLinks(t *testing.T) {
+	s := `<p><a href="/a">first</a>, <a href="/b" title="B">second</a> and <a href="/a">again</a></p>`
+	expect := "[first][1], [second][2] and [again][1]\n\n" +
+		"[1]: /a\n" +
+		"[2]: /b \"B\"\n"
+
+	doc, _ := Parse(strings.NewReader(s))
+	p := doc.QuerySelector("p")
+	if actual := p.Markdown(&MarkdownOptions{ReferenceLinks: true}); actual != expect {
+		t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, expect)
+	}
+}
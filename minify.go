@@ -0,0 +1,459 @@
+package gohtml
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// MinifyOptions is the options for Minify. the zero value
+// minifies as much as possible except the document tags
+type MinifyOptions struct {
+	// KeepComments keeps all of the comments
+	KeepComments bool
+	// KeepConditionalComments keeps the comments like <!--[if IE]>
+	KeepConditionalComments bool
+	// KeepLicenseComments keeps the comments start with "!" like <!--! ... -->
+	KeepLicenseComments bool
+	// KeepWhitespace does not collapse white space in the text
+	KeepWhitespace bool
+	// KeepEndTags does not drop optional end tags
+	KeepEndTags bool
+	// KeepQuotes does not drop quotes around attribute values
+	KeepQuotes bool
+	// KeepDefaultAttributes does not remove attributes that have the
+	// default value, e.g. type="text/javascript" of <script>
+	KeepDefaultAttributes bool
+	// RemoveDocumentTags omits <html>, <head> and <body> tags if it is safe
+	RemoveDocumentTags bool
+}
+
+// Minify writes the minified HTML of the document to w
+func (d Document) Minify(w io.Writer, opts *MinifyOptions) error {
+	return minify(w, d.Node, opts)
+}
+
+// Minify writes the minified HTML of the element to w
+func (e Element) Minify(w io.Writer, opts *MinifyOptions) error {
+	return minify(w, e.Node, opts)
+}
+
+func minify(w io.Writer, n *html.Node, opts *MinifyOptions) error {
+	m := &minifier{}
+	if opts != nil {
+		m.opts = *opts
+	}
+	m.node(n, nil, preserved(n))
+	_, err := io.WriteString(w, m.b.String())
+	return err
+}
+
+type minifier struct {
+	opts MinifyOptions
+	b    strings.Builder
+}
+
+// minNode is the node to be written with the minified text
+type minNode struct {
+	n    *html.Node
+	text string
+}
+
+var booleanAttributes = map[string]bool{
+	"allowfullscreen": true, "async": true, "autofocus": true, "autoplay": true,
+	"checked": true, "controls": true, "default": true, "defer": true,
+	"disabled": true, "formnovalidate": true, "hidden": true, "inert": true,
+	"ismap": true, "itemscope": true, "loop": true, "multiple": true,
+	"muted": true, "nomodule": true, "novalidate": true, "open": true,
+	"playsinline": true, "readonly": true, "required": true, "reversed": true,
+	"selected": true,
+}
+
+// defaultAttributes is the attribute values can be removed for each element
+var defaultAttributes = map[string]map[string]string{
+	"area":     {"shape": "rect"},
+	"button":   {"type": "submit"},
+	"form":     {"method": "get", "autocomplete": "on"},
+	"input":    {"type": "text"},
+	"link":     {"type": "text/css"},
+	"script":   {"type": "text/javascript", "language": "javascript"},
+	"style":    {"type": "text/css", "media": "all"},
+	"td":       {"colspan": "1", "rowspan": "1"},
+	"th":       {"colspan": "1", "rowspan": "1"},
+	"textarea": {"wrap": "soft"},
+}
+
+// preserved returns true if the white space in n is meaningful
+func preserved(n *html.Node) bool {
+	for p := n; p != nil; p = p.Parent {
+		if isHTML(p, preserveElements) || isHTML(p, rawTextElements) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *minifier) node(n *html.Node, next *html.Node, preserve bool) {
+	switch n.Type {
+	case html.DocumentNode:
+		m.children(n, preserve)
+	case html.ElementNode:
+		m.element(n, next, preserve)
+	case html.TextNode:
+		m.b.WriteString(escapeText(n.Data))
+	default:
+		html.Render(&m.b, n)
+	}
+}
+
+func (m *minifier) element(n *html.Node, next *html.Node, preserve bool) {
+	if isHTML(n, preserveElements) || isHTML(n, rawTextElements) {
+		preserve = true
+	}
+
+	if !m.omitStartTag(n) {
+		m.startTag(n)
+	}
+	if n.Namespace == "" && voidElements[n.Data] {
+		return
+	}
+	if n.Namespace != "" && n.FirstChild == nil {
+		return
+	}
+
+	if isHTML(n, rawTextElements) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				m.b.WriteString(c.Data)
+			}
+		}
+	} else {
+		// the first newline of <pre> is dropped by the parser
+		if isHTML(n, preserveElements) && n.FirstChild != nil &&
+			n.FirstChild.Type == html.TextNode && strings.HasPrefix(n.FirstChild.Data, "\n") {
+			m.b.WriteString("\n")
+		}
+		m.children(n, preserve)
+	}
+
+	if !m.omitEndTag(n, next) {
+		m.b.WriteString("</" + n.Data + ">")
+	}
+}
+
+func (m *minifier) children(n *html.Node, preserve bool) {
+	nodes := m.collect(n, preserve)
+	for i, c := range nodes {
+		var next *html.Node
+		if i+1 < len(nodes) {
+			next = nodes[i+1].n
+		}
+		if c.n.Type == html.TextNode {
+			m.b.WriteString(escapeText(c.text))
+			continue
+		}
+		m.node(c.n, next, preserve)
+	}
+}
+
+// collect returns the child nodes to be written. the comments are
+// removed and the white space in the text is collapsed
+func (m *minifier) collect(n *html.Node, preserve bool) []minNode {
+	var nodes []minNode
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case html.CommentNode:
+			if m.keepComment(c.Data) {
+				nodes = append(nodes, minNode{n: c})
+			}
+		case html.TextNode:
+			text := c.Data
+			if !preserve && !m.opts.KeepWhitespace {
+				text = collapseSpace(text)
+			}
+			// the text nodes become adjacent by removing comments
+			if last := len(nodes) - 1; last >= 0 && nodes[last].n.Type == html.TextNode {
+				nodes[last].text = collapseSpace(nodes[last].text + text)
+				continue
+			}
+			nodes = append(nodes, minNode{n: c, text: text})
+		default:
+			nodes = append(nodes, minNode{n: c})
+		}
+	}
+	if preserve || m.opts.KeepWhitespace {
+		return nodes
+	}
+
+	// white space next to a block is not rendered
+	kept := nodes[:0]
+	for i, c := range nodes {
+		if c.n.Type == html.TextNode {
+			if isBlockBoundary(nodes, i, -1, n) {
+				c.text = strings.TrimLeft(c.text, " ")
+			}
+			if isBlockBoundary(nodes, i, 1, n) {
+				c.text = strings.TrimRight(c.text, " ")
+			}
+			if c.text == "" {
+				continue
+			}
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+// blockElements are the HTML elements laid out as a block by default.
+// the foreign and the unknown elements are laid out in a line
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"body": true, "caption": true, "center": true, "col": true,
+	"colgroup": true, "dd": true, "details": true, "dialog": true,
+	"dir": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true,
+	"frameset": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "head": true, "header": true, "hgroup": true,
+	"hr": true, "html": true, "legend": true, "li": true, "listing": true,
+	"main": true, "menu": true, "nav": true, "ol": true, "optgroup": true,
+	"option": true, "p": true, "plaintext": true, "pre": true,
+	"search": true, "section": true, "summary": true, "table": true,
+	"tbody": true, "td": true, "tfoot": true, "th": true, "thead": true,
+	"tr": true, "ul": true, "xmp": true,
+}
+
+// spaceTrimmed are the elements whose white space is not rendered,
+// the title is stripped by document.title
+var spaceTrimmed = map[string]bool{"head": true, "html": true, "title": true}
+
+// isBlockBoundary returns true if the white space between the text
+// nodes[i] and the sibling in the direction is not rendered
+func isBlockBoundary(nodes []minNode, i, direction int, parent *html.Node) bool {
+	if parent.Type != html.ElementNode || isHTML(parent, spaceTrimmed) {
+		return true
+	}
+	j := i + direction
+	for j >= 0 && j < len(nodes) && nodes[j].n.Type == html.CommentNode {
+		j += direction
+	}
+	if j < 0 || j >= len(nodes) {
+		return isHTML(parent, blockElements)
+	}
+	return isHTML(nodes[j].n, blockElements)
+}
+
+// collapseSpace replaces each run of white space with single space
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if isSpaceRune(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+func (m *minifier) keepComment(data string) bool {
+	switch {
+	case m.opts.KeepComments:
+		return true
+	case m.opts.KeepConditionalComments && (strings.HasPrefix(data, "[if") || strings.HasSuffix(data, "<![endif]")):
+		return true
+	case m.opts.KeepLicenseComments && strings.HasPrefix(data, "!"):
+		return true
+	}
+	return false
+}
+
+func (m *minifier) startTag(n *html.Node) {
+	m.b.WriteString("<" + n.Data)
+	unquoted := false
+	for _, a := range n.Attr {
+		if !m.opts.KeepDefaultAttributes && n.Namespace == "" && a.Namespace == "" {
+			if v, ok := defaultAttributes[n.Data][a.Key]; ok && strings.EqualFold(strings.TrimSpace(a.Val), v) {
+				continue
+			}
+		}
+
+		m.b.WriteString(" " + attrName(a))
+		unquoted = false
+		switch {
+		case n.Namespace == "" && a.Namespace == "" && booleanAttributes[a.Key] &&
+			(a.Val == "" || strings.EqualFold(a.Val, a.Key)):
+		case m.opts.KeepQuotes:
+			m.b.WriteString(`="` + escapeAttr(a.Val, '"') + `"`)
+		case a.Val != "":
+			v := quoteAttr(a.Val)
+			unquoted = v[0] != '"' && v[0] != '\''
+			m.b.WriteString("=" + v)
+		}
+	}
+	if n.Namespace != "" && n.FirstChild == nil {
+		// the slash just after an unquoted value is a part of the value
+		if unquoted {
+			m.b.WriteByte(' ')
+		}
+		m.b.WriteString("/")
+	}
+	m.b.WriteString(">")
+}
+
+// escapeText escapes the characters needed in text
+func escapeText(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;").Replace(s)
+}
+
+// escapeAttr escapes the characters needed in the attribute value quoted by q
+func escapeAttr(s string, q byte) string {
+	if q == '\'' {
+		return strings.NewReplacer("&", "&amp;", "'", "&#39;").Replace(s)
+	}
+	return strings.NewReplacer("&", "&amp;", `"`, "&#34;").Replace(s)
+}
+
+// quoteAttr returns the shortest form of the attribute value
+func quoteAttr(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\r\f\"'=<>`") {
+		return escapeAttr(s, '"')
+	}
+	if strings.Contains(s, `"`) && !strings.Contains(s, "'") {
+		return "'" + escapeAttr(s, '\'') + "'"
+	}
+	return `"` + escapeAttr(s, '"') + `"`
+}
+
+// firstChild returns the first child that is not an removed comment
+func (m *minifier) firstChild(n *html.Node) *html.Node {
+	c := n.FirstChild
+	for c != nil && c.Type == html.CommentNode && !m.keepComment(c.Data) {
+		c = c.NextSibling
+	}
+	return c
+}
+
+// omitStartTag returns true if the start tag of the document element can be omitted
+func (m *minifier) omitStartTag(n *html.Node) bool {
+	if !m.opts.RemoveDocumentTags || n.Namespace != "" || len(n.Attr) > 0 {
+		return false
+	}
+	first := m.firstChild(n)
+	switch n.Data {
+	case "html":
+		return first == nil || first.Type != html.CommentNode
+	case "head":
+		return first == nil || first.Type == html.ElementNode
+	case "body":
+		if first == nil {
+			return true
+		}
+		switch first.Type {
+		case html.CommentNode:
+			return false
+		case html.TextNode:
+			return first.Data == "" || !isSpaceRune(rune(first.Data[0]))
+		case html.ElementNode:
+			switch first.Data {
+			case "meta", "link", "script", "style", "template":
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// pClosers are the elements that close <p>
+var pClosers = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"details": true, "div": true, "dl": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hgroup": true, "hr": true, "main": true, "menu": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "search": true,
+	"section": true, "table": true, "ul": true,
+}
+
+// omitEndTag returns true if the end tag of n can be omitted
+// when it is followed by next. next is nil if n is the last child
+func (m *minifier) omitEndTag(n, next *html.Node) bool {
+	if n.Namespace != "" {
+		return false
+	}
+
+	nextIs := func(tags ...string) bool {
+		if next == nil || next.Type != html.ElementNode || next.Namespace != "" {
+			return false
+		}
+		for _, t := range tags {
+			if next.Data == t {
+				return true
+			}
+		}
+		return false
+	}
+	notComment := next == nil || next.Type != html.CommentNode
+	notSpace := notComment && (next == nil || next.Type != html.TextNode ||
+		next.Data == "" || !isSpaceRune(rune(next.Data[0])))
+
+	switch n.Data {
+	case "html", "body":
+		return m.opts.RemoveDocumentTags && notComment
+	case "head":
+		return m.opts.RemoveDocumentTags && notSpace
+	}
+	if m.opts.KeepEndTags {
+		return false
+	}
+
+	switch n.Data {
+	case "li":
+		return next == nil || nextIs("li")
+	case "dt":
+		return nextIs("dt", "dd")
+	case "dd":
+		return next == nil || nextIs("dt", "dd")
+	case "p":
+		if next == nil {
+			p := n.Parent
+			if p == nil || p.Type != html.ElementNode || p.Namespace != "" {
+				return false
+			}
+			switch p.Data {
+			case "a", "audio", "del", "ins", "map", "noscript", "video":
+				return false
+			}
+			return !strings.Contains(p.Data, "-")
+		}
+		return next.Type == html.ElementNode && next.Namespace == "" && pClosers[next.Data]
+	case "rt", "rp":
+		return next == nil || nextIs("rt", "rp")
+	case "optgroup":
+		return next == nil || nextIs("optgroup", "hr")
+	case "option":
+		return next == nil || nextIs("option", "optgroup", "hr")
+	case "colgroup", "caption":
+		return notSpace
+	case "thead":
+		return nextIs("tbody", "tfoot")
+	case "tbody":
+		return next == nil || nextIs("tbody", "tfoot")
+	case "tfoot":
+		return next == nil
+	case "tr":
+		return next == nil || nextIs("tr")
+	case "td", "th":
+		return next == nil || nextIs("td", "th")
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package gohtml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const test_minify = `<!DOCTYPE html>
+<html>
+<head>
+  <!--[if IE]><p>ie</p><![endif]-->
+  <title> Title </title>
+  <script type="text/javascript">
+    var a = "<b>";
+  </script>
+</head>
+<body>
+  <!--! license -->
+  <!-- comment -->
+  <div   class="a b"  id="x">
+    Hello,   <b>world</b> !
+  </div>
+  <ul>
+    <li>one</li>
+    <li>two</li>
+  </ul>
+  <p>para</p>
+  <pre>
+  keep   this
+</pre>
+  <input type="text" disabled="disabled" value="">
+  <a href='say "hi"'>x</a>
+</body>
+</html>
+`
+
+func TestMinify(t *testing.T) {
+	tests := []struct {
+		opts   MinifyOptions
+		expect string
+	}{
+		{
+			MinifyOptions{},
+			`<!DOCTYPE html><html><head><title>Title</title><script>
+    var a = "<b>";
+  </script></head><body><div class="a b" id=x>Hello, <b>world</b> !</div><ul><li>one<li>two</ul><p>para<pre>  keep   this
+</pre><input disabled value> <a href='say "hi"'>x</a></body></html>`,
+		},
+		{
+			MinifyOptions{KeepConditionalComments: true, KeepLicenseComments: true, RemoveDocumentTags: true},
+			`<!DOCTYPE html><head><!--[if IE]><p>ie</p><![endif]--><title>Title</title><script>
+    var a = "<b>";
+  </script><body><!--! license --><div class="a b" id=x>Hello, <b>world</b> !</div><ul><li>one<li>two</ul><p>para<pre>  keep   this
+</pre><input disabled value> <a href='say "hi"'>x</a>`,
+		},
+		{
+			MinifyOptions{KeepEndTags: true, KeepQuotes: true, KeepDefaultAttributes: true},
+			`<!DOCTYPE html><html><head><title>Title</title><script type="text/javascript">
+    var a = "<b>";
+  </script></head><body><div class="a b" id="x">Hello, <b>world</b> !</div><ul><li>one</li><li>two</li></ul><p>para</p><pre>  keep   this
+</pre><input type="text" disabled value=""> <a href="say &#34;hi&#34;">x</a></body></html>`,
+		},
+	}
+
+	for i, test := range tests {
+		doc, _ := Parse(strings.NewReader(test_minify))
+		var buf bytes.Buffer
+		if err := doc.Minify(&buf, &test.opts); err != nil {
+			t.Fatal(err)
+		}
+		actual := buf.String()
+		if actual != test.expect {
+			t.Errorf("\n%d: got :\n%s\nwant:\n%s\n", i, actual, test.expect)
+		}
+
+		// must be the same tree after parsing again
+		again, _ := Parse(strings.NewReader(actual))
+		if a, b := again.QuerySelector("pre").TextContent(), doc.QuerySelector("pre").TextContent(); a != b {
+			t.Errorf("\n%d: got : %q, want: %q\n", i, a, b)
+		}
+		if a, b := again.QuerySelectorAll("li").Length(), 2; a != b {
+			t.Errorf("\n%d: got : %d, want: %d\n", i, a, b)
+		}
+	}
+}
+
+func TestMinifyOptionalEndTags(t *testing.T) {
+	s := `<table><thead><tr><th>a</th></tr></thead><tbody><tr><td>1</td><td>2</td></tr><tr><td>3</td></tr></tbody></table><dl><dt>t</dt><dd>d</dd></dl><select><option>1</option><option>2</option></select><div><p>x</p></div><a><p>y</p></a>`
+	expect := `<table><thead><tr><th>a<tbody><tr><td>1<td>2<tr><td>3</table><dl><dt>t<dd>d</dl><select><option>1<option>2</select><div><p>x</div><a><p>y</p></a>`
+
+	doc, _ := Parse(strings.NewReader(s))
+	var buf bytes.Buffer
+	doc.Body().Minify(&buf, &MinifyOptions{})
+	actual := strings.TrimSuffix(strings.TrimPrefix(buf.String(), "<body>"), "</body>")
+	if actual != expect {
+		t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, expect)
+	}
+
+	again, _ := Parse(strings.NewReader(actual))
+	if a, b := again.Body().InnerHTML(), doc.Body().InnerHTML(); a != b {
+		t.Errorf("\nparsed again:\n%s\nwant:\n%s\n", a, b)
+	}
+}
+
+func TestMinifyAttributes(t *testing.T) {
+	s := `<div hidden="until-found" title="t"></div><input disabled="disabled" checked=""><svg><circle fill="red"/><rect fill="a b"/><path d/></svg>`
+	expect := `<div hidden=until-found title=t></div><input disabled checked><svg><circle fill=red /><rect fill="a b"/><path d/></svg>`
+
+	doc, _ := Parse(strings.NewReader(s))
+	var buf bytes.Buffer
+	doc.Body().Minify(&buf, &MinifyOptions{})
+	actual := strings.TrimSuffix(strings.TrimPrefix(buf.String(), "<body>"), "</body>")
+	if actual != expect {
+		t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, expect)
+	}
+
+	// the values other than the boolean attributes must be kept
+	again, _ := Parse(strings.NewReader(actual))
+	for _, selector := range []string{"div", "svg"} {
+		if a, b := again.QuerySelector(selector).OuterHTML(), doc.QuerySelector(selector).OuterHTML(); a != b {
+			t.Errorf("\nparsed again:\n%s\nwant:\n%s\n", a, b)
+		}
+	}
+}
+
+func TestMinifySpaces(t *testing.T) {
+	tests := []struct {
+		input, expect string
+	}{
+		{`Home <svg><path d="M0"></path></svg> next`, `Home <svg><path d=M0 /></svg> next`},
+		{`a <math><mi>x</mi></math> b`, `a <math><mi>x</mi></math> b`},
+		{`a <my-icon>i</my-icon> b`, `a <my-icon>i</my-icon> b`},
+		{`<div> a <p> b </p> c </div>`, `<div>a<p>b</p>c</div>`},
+	}
+	for _, tt := range tests {
+		doc, _ := Parse(strings.NewReader(tt.input))
+		var buf bytes.Buffer
+		doc.Body().Minify(&buf, &MinifyOptions{})
+		actual := strings.TrimSuffix(strings.TrimPrefix(buf.String(), "<body>"), "</body>")
+		if actual != tt.expect {
+			t.Errorf("\ngot : %s\nwant: %s\n", actual, tt.expect)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Package morph changes a live tree into a new state in place with
+// minimal operations, like morphdom. the unchanged nodes are kept
+// and the applied operations are returned to replay them elsewhere
+package morph
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/saihon/gohtml"
+	"github.com/saihon/gohtml/patch"
+	"github.com/saihon/gohtml/utils"
+)
+
+// Op is an applied operation. it is patch.Op itself, so the
+// operations can be replayed by patch.ApplyElement on the copy of
+// the target. the paths are relative to the target
+type Op = patch.Op
+
+// Options is the options of Morph
+type Options struct {
+	// Key is the attribute that matches the elements
+	// regardless of the position. "id" if empty
+	Key string
+	// ChildrenOnly changes only the children of the target,
+	// the attributes of the target itself are not changed
+	ChildrenOnly bool
+}
+
+// ErrMismatch is returned if the target is not the same kind as the source
+var ErrMismatch = errors.New("morph: target and source are not the same kind of node")
+
+// Morph changes target in place to be the same as source. source is not changed
+func Morph(target, source *gohtml.Element, opts *Options) ([]Op, error) {
+	m := &morpher{root: target.Node, key: "id"}
+	if opts != nil {
+		if opts.Key != "" {
+			m.key = opts.Key
+		}
+		if opts.ChildrenOnly {
+			m.children(target.Node, source.Node)
+			return m.ops, nil
+		}
+	}
+	if !compatible(target.Node, source.Node) {
+		return nil, ErrMismatch
+	}
+	m.node(target.Node, source.Node)
+	return m.ops, nil
+}
+
+// HTML changes target in place to be the element of s. if opts.ChildrenOnly
+// is true, s is the new inner HTML, and otherwise the new outer HTML
+func HTML(target *gohtml.Element, s string, opts *Options) ([]Op, error) {
+	context := target.Node
+	if opts == nil || !opts.ChildrenOnly {
+		if context = target.Node.Parent; context == nil || context.Type != html.ElementNode {
+			context = &html.Node{Type: html.ElementNode, Data: "body"}
+		}
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts != nil && opts.ChildrenOnly {
+		source := &html.Node{Type: html.ElementNode}
+		for _, n := range nodes {
+			source.AppendChild(n)
+		}
+		return Morph(target, &gohtml.Element{Node: source}, opts)
+	}
+
+	var source *html.Node
+	for _, n := range nodes {
+		if n.Type == html.TextNode && strings.TrimSpace(n.Data) == "" {
+			continue
+		}
+		if source != nil || n.Type != html.ElementNode {
+			return nil, errors.New("morph: HTML must be one element")
+		}
+		source = n
+	}
+	if source == nil {
+		return nil, errors.New("morph: HTML must be one element")
+	}
+	return Morph(target, &gohtml.Element{Node: source}, opts)
+}
+
+type morpher struct {
+	root *html.Node
+	key  string
+	ops  []Op
+}
+
+func (m *morpher) path(n *html.Node) []int {
+	return utils.Path(m.root, n)
+}
+
+// compatible returns true if t can be changed into s without replacing
+func compatible(t, s *html.Node) bool {
+	if t.Type != s.Type {
+		return false
+	}
+	if t.Type == html.ElementNode {
+		return t.Data == s.Data && t.Namespace == s.Namespace
+	}
+	return t.Type == html.TextNode || t.Type == html.CommentNode
+}
+
+// node changes t into s, which are compatible
+func (m *morpher) node(t, s *html.Node) {
+	e := &gohtml.Element{Node: t}
+	if t.Type != html.ElementNode {
+		if t.Data != s.Data {
+			e.NodeValue(s.Data)
+			m.ops = append(m.ops, Op{Op: "replaceText", Path: m.path(t), Value: s.Data})
+		}
+		return
+	}
+
+	for _, a := range s.Attr {
+		if v, ok := e.GetAttributeNodeNS(a.Namespace, a.Key); !ok || v.Val != a.Val {
+			e.SetAttributeNodeNS(a)
+			m.ops = append(m.ops, Op{Op: "setAttribute", Path: m.path(t), Namespace: a.Namespace, Key: a.Key, Value: a.Val})
+		}
+	}
+	for _, a := range append([]html.Attribute(nil), t.Attr...) {
+		if _, ok := (&gohtml.Element{Node: s}).GetAttributeNodeNS(a.Namespace, a.Key); !ok {
+			e.RemoveAttributeNode(a)
+			m.ops = append(m.ops, Op{Op: "removeAttribute", Path: m.path(t), Namespace: a.Namespace, Key: a.Key})
+		}
+	}
+	m.children(t, s)
+}
+
+// keyOf returns the key of the element, or empty string
+func (m *morpher) keyOf(n *html.Node) string {
+	if n.Type != html.ElementNode {
+		return ""
+	}
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == m.key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// children changes the children of t into the children of s.
+// the keyed children are matched by the key, and the others are
+// matched by the position if compatible
+func (m *morpher) children(t, s *html.Node) {
+	parent := &gohtml.Element{Node: t}
+
+	keyed := map[string]*html.Node{}
+	for c := t.FirstChild; c != nil; c = c.NextSibling {
+		if k := m.keyOf(c); k != "" && keyed[k] == nil {
+			keyed[k] = c
+		}
+	}
+	wanted := map[string]bool{}
+	for c := s.FirstChild; c != nil; c = c.NextSibling {
+		if k := m.keyOf(c); k != "" && keyed[k] != nil && compatible(keyed[k], c) {
+			wanted[k] = true
+		}
+	}
+
+	// the wanted keyed nodes are not matched by the position, and
+	// the new nodes are inserted before the current node
+	cur := t.FirstChild
+	for sc := s.FirstChild; sc != nil; sc = sc.NextSibling {
+		// the keyed nodes not in the source are never matched
+		for cur != nil && m.keyOf(cur) != "" && !wanted[m.keyOf(cur)] {
+			next := cur.NextSibling
+			m.remove(parent, cur)
+			cur = next
+		}
+
+		if k := m.keyOf(sc); wanted[k] {
+			tk := keyed[k]
+			delete(wanted, k)
+			if tk == cur {
+				cur = cur.NextSibling
+			} else {
+				m.move(parent, tk, cur)
+			}
+			m.node(tk, sc)
+			continue
+		}
+
+		if cur != nil && !wanted[m.keyOf(cur)] && compatible(cur, sc) && m.keyOf(cur) == m.keyOf(sc) {
+			next := cur.NextSibling
+			m.node(cur, sc)
+			cur = next
+			continue
+		}
+		m.insert(parent, sc, cur)
+	}
+
+	for cur != nil {
+		next := cur.NextSibling
+		m.remove(parent, cur)
+		cur = next
+	}
+}
+
+// remove removes n
+func (m *morpher) remove(parent *gohtml.Element, n *html.Node) {
+	m.ops = append(m.ops, Op{Op: "remove", Path: m.path(n)})
+	parent.RemoveChild(&gohtml.Element{Node: n})
+}
+
+// pivot returns the path and the position to insert before cur in parent
+func (m *morpher) pivot(parent *gohtml.Element, cur *html.Node) ([]int, string) {
+	if cur == nil {
+		return m.path(parent.Node), "beforeend"
+	}
+	return m.path(cur), "beforebegin"
+}
+
+// insert inserts the copy of sc before cur
+func (m *morpher) insert(parent *gohtml.Element, sc, cur *html.Node) {
+	path, position := m.pivot(parent, cur)
+	m.ops = append(m.ops, Op{Op: "insertHTML", Path: path, Position: position, HTML: utils.HTML(sc)})
+
+	n := &gohtml.Element{Node: utils.CloneAll(sc)}
+	if cur == nil {
+		parent.AppendChild(n)
+	} else {
+		parent.InsertBefore(n, &gohtml.Element{Node: cur})
+	}
+}
+
+// move moves n before cur
+func (m *morpher) move(parent *gohtml.Element, n, cur *html.Node) {
+	from := m.path(n)
+	parent.RemoveChild(&gohtml.Element{Node: n})
+	path, position := m.pivot(parent, cur)
+	m.ops = append(m.ops, Op{Op: "move", From: from, Path: path, Position: position})
+
+	if cur == nil {
+		parent.AppendChild(&gohtml.Element{Node: n})
+	} else {
+		parent.InsertBefore(&gohtml.Element{Node: n}, &gohtml.Element{Node: cur})
+	}
+}
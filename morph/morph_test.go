@@ -0,0 +1,93 @@
+package morph
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/saihon/gohtml"
+	"github.com/saihon/gohtml/patch"
+)
+
+func parse(s string) *gohtml.Document {
+	doc, _ := gohtml.Parse(strings.NewReader(s))
+	return doc
+}
+
+func TestMorph(t *testing.T) {
+	doc := parse(`<ul id="list" class="old"><li id="a">A</li><li id="b">B</li><li id="c">C</li><li>x</li></ul>`)
+	target := doc.QuerySelector("ul")
+	b := doc.GetElementById("b")
+
+	source := parse(`<ul id="list"><li id="c">C</li><li id="b" class="on">B!</li><li>y</li><li>z</li></ul>`).QuerySelector("ul")
+
+	ops, err := Morph(target, source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expect := target.OuterHTML(), source.OuterHTML(); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+	if doc.GetElementById("b").Node != b.Node {
+		t.Errorf("\nthe keyed element is replaced\n")
+	}
+
+	data, _ := json.Marshal(ops)
+	expect := `[{"op":"removeAttribute","path":[],"key":"class"},` +
+		`{"op":"remove","path":[0]},` +
+		`{"op":"move","path":[0],"from":[1],"position":"beforebegin"},` +
+		`{"op":"setAttribute","path":[1],"key":"class","value":"on"},` +
+		`{"op":"replaceText","path":[1,0],"value":"B!"},` +
+		`{"op":"replaceText","path":[2,0],"value":"y"},` +
+		`{"op":"insertHTML","path":[],"position":"beforeend","html":"\u003cli\u003ez\u003c/li\u003e"}]`
+	if actual := string(data); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	doc := parse(`<div><p>one</p><p>two</p></div>`)
+	div := doc.QuerySelector("div")
+	first := div.FirstElementChild()
+
+	ops, err := HTML(div, `<p>one</p><p>three</p><table><tr><td>x</td></tr></table>`, &Options{ChildrenOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `<div><p>one</p><p>three</p><table><tbody><tr><td>x</td></tr></tbody></table></div>`
+	if actual := div.OuterHTML(); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+	if div.FirstElementChild().Node != first.Node {
+		t.Errorf("\nthe unchanged element is replaced\n")
+	}
+	if len(ops) != 2 {
+		t.Errorf("\ngot : %d ops, want: 2\n", len(ops))
+	}
+
+	if _, err := HTML(div, `<span></span>`, nil); err != ErrMismatch {
+		t.Errorf("\ngot : %v, want: %v\n", err, ErrMismatch)
+	}
+	if _, err := HTML(div, `<div></div><div></div>`, nil); err == nil {
+		t.Errorf("\nexpected an error\n")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	s := `<div><h1 id="t">Title</h1><p>one</p><ul><li id="a">A</li><li id="b">B</li></ul><!-- c --></div>`
+	doc := parse(s)
+	copy := parse(s)
+
+	source := parse(`<div><ul><li id="b">B</li><li id="c">C</li><li id="a">A2</li></ul><p class="x">two</p><h1 id="t">Title</h1></div>`).QuerySelector("div")
+	ops, err := Morph(doc.QuerySelector("div"), source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := patch.ApplyElement(copy.QuerySelector("div"), ops); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expect := copy.QuerySelector("div").OuterHTML(), source.OuterHTML(); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+}
@@ -0,0 +1,336 @@
+package gohtml
+
+import (
+	"errors"
+	"sync"
+
+	"golang.org/x/net/html"
+
+	"github.com/saihon/gohtml/utils"
+)
+
+// MutationRecord is a change made to the tree through gohtml,
+// like MutationRecord of JavaScript
+type MutationRecord struct {
+	// Type is "childList", "attributes" or "characterData"
+	Type string
+	// Target is the parent of the changed children, the element of the
+	// changed attribute, or the changed text or comment node
+	Target *html.Node
+	// AddedNodes and RemovedNodes are the children added and removed
+	AddedNodes   []*html.Node
+	RemovedNodes []*html.Node
+	// PreviousSibling and NextSibling are the siblings
+	// of the added or the removed children
+	PreviousSibling *html.Node
+	NextSibling     *html.Node
+	// AttributeName and AttributeNamespace are the name of the changed attribute
+	AttributeName      string
+	AttributeNamespace string
+	// OldValue is the value of the attribute or the text before the change.
+	// set only if the observer requests it by AttributeOldValue
+	// or CharacterDataOldValue
+	OldValue string
+
+	// oldValue is kept regardless of the options
+	oldValue string
+	// oldIndex is the index of the attribute before the change,
+	// -1 if the attribute did not exist
+	oldIndex int
+}
+
+// MutationObserverInit is the options of MutationObserver.Observe.
+// Attributes and CharacterData are implied by their other options
+type MutationObserverInit struct {
+	ChildList             bool
+	Attributes            bool
+	CharacterData         bool
+	Subtree               bool
+	AttributeOldValue     bool
+	CharacterDataOldValue bool
+	// AttributeFilter is the local names of the attributes to observe.
+	// all attributes are observed if empty
+	AttributeFilter []string
+}
+
+// MutationCallback is called with the records queued for the observer
+type MutationCallback func(records []MutationRecord, observer *MutationObserver)
+
+// MutationObserver receives the records of the changes made to the
+// observed nodes. the callback is called synchronously when each
+// mutating method returns, with the records that have been queued.
+// if the callback is nil, the records are kept until TakeRecords.
+// unlike JavaScript, the changes in the nodes removed from the observed
+// subtree are not reported. the observed nodes are referenced until
+// Disconnect even if they are removed from the tree, so Disconnect
+// must be called when the observer is no longer used
+type MutationObserver struct {
+	callback MutationCallback
+
+	mu      sync.Mutex
+	records []MutationRecord
+	nodes   []*html.Node
+}
+
+type registration struct {
+	observer *MutationObserver
+	options  MutationObserverInit
+}
+
+var (
+	observeMu sync.Mutex
+	observed  map[*html.Node][]*registration
+)
+
+// NewMutationObserver returns the observer calls the callback
+func NewMutationObserver(callback MutationCallback) *MutationObserver {
+	return &MutationObserver{callback: callback}
+}
+
+// Observe starts observing the node with the options. observing
+// the same node again replaces the options. the node is kept
+// until Disconnect
+func (o *MutationObserver) Observe(n *html.Node, options MutationObserverInit) error {
+	if options.AttributeOldValue || len(options.AttributeFilter) > 0 {
+		options.Attributes = true
+	}
+	if options.CharacterDataOldValue {
+		options.CharacterData = true
+	}
+	if !options.ChildList && !options.Attributes && !options.CharacterData {
+		return errors.New("gohtml: one of ChildList, Attributes or CharacterData must be true")
+	}
+
+	observeMu.Lock()
+	defer observeMu.Unlock()
+	if observed == nil {
+		observed = make(map[*html.Node][]*registration)
+	}
+	for _, r := range observed[n] {
+		if r.observer == o {
+			r.options = options
+			return nil
+		}
+	}
+	observed[n] = append(observed[n], &registration{observer: o, options: options})
+	o.mu.Lock()
+	o.nodes = append(o.nodes, n)
+	o.mu.Unlock()
+	return nil
+}
+
+// Disconnect stops observing all nodes and discards the queued records
+func (o *MutationObserver) Disconnect() {
+	observeMu.Lock()
+	o.mu.Lock()
+	for _, n := range o.nodes {
+		list := observed[n]
+		for i, r := range list {
+			if r.observer == o {
+				list = append(list[:i:i], list[i+1:]...)
+				break
+			}
+		}
+		if len(list) == 0 {
+			delete(observed, n)
+		} else {
+			observed[n] = list
+		}
+	}
+	o.nodes = nil
+	o.records = nil
+	o.mu.Unlock()
+	observeMu.Unlock()
+}
+
+// TakeRecords returns the queued records and empties the queue
+func (o *MutationObserver) TakeRecords() []MutationRecord {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	records := o.records
+	o.records = nil
+	return records
+}
+
+// observing returns true if any node is observed
+func observing() bool {
+	observeMu.Lock()
+	defer observeMu.Unlock()
+	return len(observed) > 0
+}
+
+// interested returns true if the options accept the record
+func (r *registration) interested(rec *MutationRecord, self bool) bool {
+	if !self && !r.options.Subtree {
+		return false
+	}
+	switch rec.Type {
+	case "childList":
+		return r.options.ChildList
+	case "characterData":
+		return r.options.CharacterData
+	}
+	if !r.options.Attributes {
+		return false
+	}
+	if len(r.options.AttributeFilter) == 0 {
+		return true
+	}
+	if rec.AttributeNamespace != "" {
+		return false
+	}
+	for _, name := range r.options.AttributeFilter {
+		if name == rec.AttributeName {
+			return true
+		}
+	}
+	return false
+}
+
+// notify queues the record for the observers of the target and its
+// ancestors, then calls their callbacks
+func notify(rec MutationRecord) {
+	type interest struct {
+		observer *MutationObserver
+		oldValue bool
+	}
+	var list []interest
+
+	observeMu.Lock()
+	for n := rec.Target; n != nil; n = n.Parent {
+		for _, r := range observed[n] {
+			if !r.interested(&rec, n == rec.Target) {
+				continue
+			}
+			oldValue := r.options.AttributeOldValue && rec.Type == "attributes" ||
+				r.options.CharacterDataOldValue && rec.Type == "characterData"
+			found := false
+			for i := range list {
+				if list[i].observer == r.observer {
+					list[i].oldValue = list[i].oldValue || oldValue
+					found = true
+				}
+			}
+			if !found {
+				list = append(list, interest{r.observer, oldValue})
+			}
+		}
+	}
+	observeMu.Unlock()
+
+	for _, in := range list {
+		r := rec
+		if in.oldValue {
+			r.OldValue = rec.oldValue
+		}
+		in.observer.mu.Lock()
+		in.observer.records = append(in.observer.records, r)
+		in.observer.mu.Unlock()
+	}
+	for _, in := range list {
+		if in.observer.callback == nil {
+			continue
+		}
+		if records := in.observer.TakeRecords(); len(records) > 0 {
+			in.observer.callback(records, in.observer)
+		}
+	}
+}
+
+// mutateChildren runs fn that changes the children of n, marks n as
+// modified and notifies the observers of the added and removed children
+func mutateChildren(n *html.Node, fn func() error) error {
+	touch(n)
+	if n == nil || !observing() {
+		return fn()
+	}
+	before := utils.ChildNodes(n)
+	err := fn()
+	after := utils.ChildNodes(n)
+
+	// the children between the common head and tail are changed
+	head := 0
+	for head < len(before) && head < len(after) && before[head] == after[head] {
+		head++
+	}
+	tail := 0
+	for tail < len(before)-head && tail < len(after)-head &&
+		before[len(before)-1-tail] == after[len(after)-1-tail] {
+		tail++
+	}
+	removed := before[head : len(before)-tail]
+	added := after[head : len(after)-tail]
+	if len(removed) == 0 && len(added) == 0 {
+		return err
+	}
+
+	rec := MutationRecord{Type: "childList", Target: n, AddedNodes: added, RemovedNodes: removed}
+	if head > 0 {
+		rec.PreviousSibling = before[head-1]
+	}
+	if tail > 0 {
+		rec.NextSibling = before[len(before)-tail]
+	}
+	notify(rec)
+	return err
+}
+
+// findAttr returns the attribute of n. namespace "*" matches any namespace
+func findAttr(n *html.Node, namespace, key string) (html.Attribute, bool) {
+	if i := attrIndex(n, namespace, key); i >= 0 {
+		return n.Attr[i], true
+	}
+	return html.Attribute{}, false
+}
+
+// attrIndex returns the index of the attribute of n, or -1
+func attrIndex(n *html.Node, namespace, key string) int {
+	for i, a := range n.Attr {
+		if a.Key == key && (namespace == "*" || a.Namespace == namespace) {
+			return i
+		}
+	}
+	return -1
+}
+
+// mutateAttr runs fn that changes the attribute of n, drops its source
+// position and notifies the observers with the old value
+func mutateAttr(n *html.Node, namespace, key string, fn func()) {
+	touchAttr(n, namespace, key)
+	if !observing() {
+		fn()
+		return
+	}
+	oldIndex := attrIndex(n, namespace, key)
+	var old html.Attribute
+	if oldIndex >= 0 {
+		old = n.Attr[oldIndex]
+	}
+	fn()
+	a, exist := findAttr(n, namespace, key)
+	if oldIndex < 0 && !exist {
+		return
+	}
+	if !exist {
+		a = old
+	}
+	notify(MutationRecord{
+		Type:               "attributes",
+		Target:             n,
+		AttributeName:      a.Key,
+		AttributeNamespace: a.Namespace,
+		oldValue:           old.Val,
+		oldIndex:           oldIndex,
+	})
+}
+
+// mutateData sets the text of the text or comment node n
+// and notifies the observers with the old text
+func mutateData(n *html.Node, data string) {
+	touch(n)
+	old := n.Data
+	n.Data = data
+	if observing() {
+		notify(MutationRecord{Type: "characterData", Target: n, oldValue: old})
+	}
+}
@@ -0,0 +1,133 @@
+package gohtml
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func nodeName(n *html.Node) string {
+	switch {
+	case n == nil:
+		return "-"
+	case n.Type == html.ElementNode:
+		return n.Data
+	}
+	return fmt.Sprintf("%q", n.Data)
+}
+
+func recordString(r MutationRecord) string {
+	switch r.Type {
+	case "childList":
+		var added, removed []string
+		for _, n := range r.AddedNodes {
+			added = append(added, nodeName(n))
+		}
+		for _, n := range r.RemovedNodes {
+			removed = append(removed, nodeName(n))
+		}
+		return fmt.Sprintf("childList %s +[%s] -[%s] %s %s", nodeName(r.Target),
+			strings.Join(added, " "), strings.Join(removed, " "),
+			nodeName(r.PreviousSibling), nodeName(r.NextSibling))
+	case "attributes":
+		return fmt.Sprintf("attributes %s %s %q", nodeName(r.Target), r.AttributeName, r.OldValue)
+	}
+	return fmt.Sprintf("characterData %s %q", nodeName(r.Target), r.OldValue)
+}
+
+func TestMutationObserver(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<div id="d" class="a"><p>one</p><p>two</p></div>`))
+	div := doc.GetElementById("d")
+
+	o := NewMutationObserver(nil)
+	defer o.Disconnect()
+	if err := o.Observe(div.Node, MutationObserverInit{ChildList: true, Subtree: true, AttributeOldValue: true, CharacterDataOldValue: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	first := div.FirstElementChild()
+	div.SetAttribute("class", "b")
+	div.RemoveAttribute("title")
+	first.SetAttribute("title", "t")
+	(&Element{Node: first.Node.FirstChild}).NodeValue("ONE")
+	span := CreateElement("span")
+	div.InsertBefore(span, first)
+	div.InsertAdjacentHTML(Beforeend, "<i>x</i><b>y</b>")
+	first.Remove()
+	// the removed node is no longer in the observed subtree
+	first.SetAttribute("title", "removed")
+	doc.Body().SetAttribute("class", "outside")
+
+	expect := []string{
+		`attributes div class "a"`,
+		`attributes p title ""`,
+		`characterData "ONE" "one"`,
+		`childList div +[span] -[] - p`,
+		`childList div +[i b] -[] p -`,
+		`childList div +[] -[p] span p`,
+	}
+	records := o.TakeRecords()
+	if len(records) != len(expect) {
+		t.Fatalf("\ngot : %d records, want: %d\n", len(records), len(expect))
+	}
+	for i, r := range records {
+		if actual := recordString(r); actual != expect[i] {
+			t.Errorf("\n%d: got : %s\nwant: %s\n", i, actual, expect[i])
+		}
+	}
+	if records := o.TakeRecords(); len(records) != 0 {
+		t.Errorf("\nthe records are not taken\n")
+	}
+
+	div.InnerText("z")
+	expect = []string{`childList div +["z"] -[span p i b] - -`}
+	records = o.TakeRecords()
+	if len(records) != len(expect) {
+		t.Fatalf("\ngot : %d records, want: %d\n", len(records), len(expect))
+	}
+	for i, r := range records {
+		if actual := recordString(r); actual != expect[i] {
+			t.Errorf("\n%d: got : %s\nwant: %s\n", i, actual, expect[i])
+		}
+	}
+
+	o.Disconnect()
+	div.SetAttribute("class", "c")
+	if records := o.TakeRecords(); len(records) != 0 {
+		t.Errorf("\nrecorded after Disconnect\n")
+	}
+}
+
+func TestMutationObserverCallback(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<div id="d"><p>one</p></div>`))
+	div := doc.GetElementById("d")
+
+	var calls [][]string
+	o := NewMutationObserver(func(records []MutationRecord, o *MutationObserver) {
+		var list []string
+		for _, r := range records {
+			list = append(list, recordString(r))
+		}
+		calls = append(calls, list)
+	})
+	defer o.Disconnect()
+
+	if err := o.Observe(div.Node, MutationObserverInit{}); err == nil {
+		t.Errorf("\nexpected an error\n")
+	}
+	if err := o.Observe(div.Node, MutationObserverInit{AttributeFilter: []string{"id"}, ChildList: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	div.SetAttribute("class", "a")
+	div.SetAttribute("id", "e")
+	div.FirstElementChild().SetAttribute("id", "x")
+	div.TextContent("text")
+
+	expect := `[[attributes div id ""] [childList div +["text"] -[p] - -]]`
+	if actual := fmt.Sprint(calls); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+}
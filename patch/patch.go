@@ -0,0 +1,262 @@
+// Package patch defines the serializable DOM operations addressed by
+// node path, and applies them to a document all-or-nothing
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/saihon/gohtml"
+	"github.com/saihon/gohtml/utils"
+)
+
+// Op is an operation. the paths are relative to the root that the patch is
+// applied to, and point to the node at the time the operation is applied.
+// a path is the indexes of the child nodes from the root, see utils.Path
+type Op struct {
+	// Op is one of "setAttribute", "removeAttribute",
+	// "replaceText", "insertHTML", "remove" and "move"
+	Op string `json:"op"`
+	// Path is the node to change, or the pivot of insertHTML and move.
+	// the pivot of move is the path after the moved node is removed
+	Path []int `json:"path"`
+	// From is the node moved by move
+	From []int `json:"from,omitempty"`
+	// Position is "beforebegin", "afterbegin", "beforeend"
+	// or "afterend" of the pivot for insertHTML and move
+	Position string `json:"position,omitempty"`
+	// Namespace and Key are the name of the attribute
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key,omitempty"`
+	// Value is the value of the attribute or the new text
+	Value string `json:"value,omitempty"`
+	// HTML is the inserted HTML
+	HTML string `json:"html,omitempty"`
+}
+
+// Patch is the list of the operations applied in order
+type Patch []Op
+
+// Error is the error of the operation in the patch
+type Error struct {
+	// Index is the index of the operation
+	Index int
+	Op    Op
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("patch: op %d (%s): %v", e.Index, e.Op.Op, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// ErrInvalidOp is the error of the operation that is malformed
+	ErrInvalidOp = errors.New("invalid operation")
+	// ErrNotFound is the error of the path or the attribute that does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrNodeType is the error of the node that cannot be changed by the operation
+	ErrNodeType = errors.New("wrong node type")
+)
+
+var positions = map[string]gohtml.Position{
+	"beforebegin": gohtml.Beforebegin,
+	"afterbegin":  gohtml.Afterbegin,
+	"beforeend":   gohtml.Beforeend,
+	"afterend":    gohtml.Afterend,
+}
+
+// Parse decodes the JSON array of the operations and validates each
+func Parse(data []byte) (Patch, error) {
+	var p Patch
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks the operations have the fields they need
+// without a document. the paths are checked by Apply
+func (p Patch) Validate() error {
+	for i, op := range p {
+		if err := validate(op); err != nil {
+			return &Error{i, op, err}
+		}
+	}
+	return nil
+}
+
+func validate(op Op) error {
+	if op.Path == nil {
+		return fmt.Errorf("%w: no path", ErrInvalidOp)
+	}
+	switch op.Op {
+	case "setAttribute", "removeAttribute":
+		if op.Key == "" {
+			return fmt.Errorf("%w: no key", ErrInvalidOp)
+		}
+	case "replaceText":
+	case "remove":
+		if len(op.Path) == 0 {
+			return fmt.Errorf("%w: cannot remove the root", ErrInvalidOp)
+		}
+	case "insertHTML", "move":
+		if _, ok := positions[op.Position]; !ok {
+			return fmt.Errorf("%w: position %q", ErrInvalidOp, op.Position)
+		}
+		if op.Op == "move" && len(op.From) == 0 {
+			return fmt.Errorf("%w: no from", ErrInvalidOp)
+		}
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrInvalidOp, op.Op)
+	}
+	return nil
+}
+
+// Apply applies the patch to the whole document. if an operation fails,
+// the document is not changed at all and the error is *Error
+func Apply(doc *gohtml.Document, p Patch) error {
+	return apply(doc.Node, p)
+}
+
+// ApplyElement applies the patch that the paths are relative to the element
+func ApplyElement(e *gohtml.Element, p Patch) error {
+	return apply(e.Node, p)
+}
+
+func apply(root *html.Node, p Patch) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	// try on the copy first, so that the root is changed only if all succeed
+	dry := utils.CloneAll(root)
+	for i, op := range p {
+		if err := do(dry, op); err != nil {
+			return &Error{i, op, err}
+		}
+	}
+	for i, op := range p {
+		if err := do(root, op); err != nil {
+			return &Error{i, op, err}
+		}
+	}
+	return nil
+}
+
+// node returns the node at the path
+func node(root *html.Node, path []int) (*html.Node, error) {
+	n := utils.NodeAt(root, path)
+	if n == nil {
+		return nil, fmt.Errorf("%w: node at %v", ErrNotFound, path)
+	}
+	return n, nil
+}
+
+func do(root *html.Node, op Op) error {
+	if op.Op == "move" {
+		return move(root, op)
+	}
+
+	n, err := node(root, op.Path)
+	if err != nil {
+		return err
+	}
+	e := &gohtml.Element{Node: n}
+
+	switch op.Op {
+	case "setAttribute", "removeAttribute":
+		if n.Type != html.ElementNode {
+			return fmt.Errorf("%w: %s of not an element", ErrNodeType, op.Op)
+		}
+		if op.Op == "setAttribute" {
+			e.SetAttributeNodeNS(html.Attribute{Namespace: op.Namespace, Key: op.Key, Val: op.Value})
+			return nil
+		}
+		if _, ok := e.GetAttributeNodeNS(op.Namespace, op.Key); !ok {
+			return fmt.Errorf("%w: attribute %q", ErrNotFound, op.Key)
+		}
+		e.RemoveAttributeNS(op.Namespace, op.Key)
+	case "replaceText":
+		if n.Type != html.TextNode && n.Type != html.CommentNode {
+			return fmt.Errorf("%w: replaceText of not a text or a comment", ErrNodeType)
+		}
+		e.NodeValue(op.Value)
+	case "insertHTML":
+		nodes, err := parse(n, op)
+		if err != nil {
+			return err
+		}
+		elements := make([]*gohtml.Element, len(nodes))
+		for i, c := range nodes {
+			elements[i] = &gohtml.Element{Node: c}
+		}
+		return insert(e, positions[op.Position], elements...)
+	case "remove":
+		(&gohtml.Element{Node: n.Parent}).RemoveChild(e)
+	}
+	return nil
+}
+
+// parse parses the HTML in the context of the parent of the inserted nodes
+func parse(pivot *html.Node, op Op) ([]*html.Node, error) {
+	context := pivot
+	if p := positions[op.Position]; p == gohtml.Beforebegin || p == gohtml.Afterend {
+		context = pivot.Parent
+	}
+	if context == nil {
+		return nil, fmt.Errorf("%w: the root has no sibling", ErrNodeType)
+	}
+	if context.Type != html.ElementNode {
+		context = &html.Node{Type: html.ElementNode, Data: "body"}
+	}
+	return html.ParseFragment(strings.NewReader(op.HTML), context)
+}
+
+// insert inserts the nodes to the position of the pivot in order
+func insert(pivot *gohtml.Element, p gohtml.Position, nodes ...*gohtml.Element) error {
+	if t := pivot.Node.Type; t != html.ElementNode && t != html.DocumentNode && (p == gohtml.Afterbegin || p == gohtml.Beforeend) {
+		return fmt.Errorf("%w: the node cannot have children", ErrNodeType)
+	}
+	// the nodes inserted after the pivot or its start tag are reversed
+	if p == gohtml.Afterbegin || p == gohtml.Afterend {
+		for i := len(nodes) - 1; i >= 0; i-- {
+			if err := pivot.InsertAdjacentElement(p, nodes[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, n := range nodes {
+		if err := pivot.InsertAdjacentElement(p, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func move(root *html.Node, op Op) error {
+	n, err := node(root, op.From)
+	if err != nil {
+		return err
+	}
+	if n == root {
+		return fmt.Errorf("%w: cannot move the root", ErrInvalidOp)
+	}
+	(&gohtml.Element{Node: n.Parent}).RemoveChild(&gohtml.Element{Node: n})
+
+	pivot, err := node(root, op.Path)
+	if err != nil {
+		return err
+	}
+	return insert(&gohtml.Element{Node: pivot}, positions[op.Position], &gohtml.Element{Node: n})
+}
@@ -0,0 +1,88 @@
+package patch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/saihon/gohtml"
+)
+
+func document(s string) *gohtml.Document {
+	doc, _ := gohtml.Parse(strings.NewReader(s))
+	return doc
+}
+
+func TestApply(t *testing.T) {
+	doc := document(`<html><head></head><body><p id="a">one</p><ul><li>x</li></ul><div>two</div></body></html>`)
+
+	p, err := Parse([]byte(`[
+		{"op":"setAttribute","path":[0,1,0],"key":"class","value":"c"},
+		{"op":"removeAttribute","path":[0,1,0],"key":"id"},
+		{"op":"replaceText","path":[0,1,0,0],"value":"ONE"},
+		{"op":"insertHTML","path":[0,1,1],"position":"beforeend","html":"<li>y</li><li>z</li>"},
+		{"op":"insertHTML","path":[0,1,1],"position":"afterbegin","html":"<li>v</li><li>w</li>"},
+		{"op":"insertHTML","path":[0,1,0],"position":"afterend","html":"<hr><br>"},
+		{"op":"move","from":[0,1,4],"path":[0,1,0],"position":"beforebegin"},
+		{"op":"remove","path":[0,1,4,2]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(doc, p); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `<body><div>two</div><p class="c">ONE</p><hr/><br/><ul><li>v</li><li>w</li><li>y</li><li>z</li></ul></body>`
+	if actual := doc.Body().OuterHTML(); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+}
+
+func TestApplyAtomic(t *testing.T) {
+	s := `<html><head></head><body><p id="a">one</p></body></html>`
+	doc := document(s)
+
+	p := Patch{
+		{Op: "setAttribute", Path: []int{0, 1, 0}, Key: "class", Value: "c"},
+		{Op: "replaceText", Path: []int{0, 1, 0}, Value: "x"},
+	}
+	err := Apply(doc, p)
+	var perr *Error
+	if !errors.As(err, &perr) || perr.Index != 1 || !errors.Is(err, ErrNodeType) {
+		t.Fatalf("\ngot : %v\n", err)
+	}
+	if actual := doc.DocumentElement().OuterHTML(); actual != s {
+		t.Errorf("\nthe document is changed: %s\n", actual)
+	}
+
+	tests := []struct {
+		p   Patch
+		err error
+	}{
+		{Patch{{Op: "removeAttribute", Path: []int{0, 1, 0}, Key: "class"}}, ErrNotFound},
+		{Patch{{Op: "remove", Path: []int{0, 1, 5}}}, ErrNotFound},
+		{Patch{{Op: "insertHTML", Path: []int{0, 1, 0}, Position: "middle"}}, ErrInvalidOp},
+		{Patch{{Op: "rename", Path: []int{0}}}, ErrInvalidOp},
+		{Patch{{Op: "remove", Path: []int{}}}, ErrInvalidOp},
+	}
+	for i, test := range tests {
+		if err := Apply(doc, test.p); !errors.Is(err, test.err) {
+			t.Errorf("\n%d: got : %v, want: %v\n", i, err, test.err)
+		}
+	}
+}
+
+func TestApplyElement(t *testing.T) {
+	doc := document(`<table><tbody><tr><td>a</td></tr></tbody></table>`)
+	tbody := doc.QuerySelector("tbody")
+
+	p := Patch{{Op: "insertHTML", Path: []int{0}, Position: "afterend", HTML: "<tr><td>b</td></tr>"}}
+	if err := ApplyElement(tbody, p); err != nil {
+		t.Fatal(err)
+	}
+	expect := `<tbody><tr><td>a</td></tr><tr><td>b</td></tr></tbody>`
+	if actual := tbody.OuterHTML(); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+}
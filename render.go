@@ -0,0 +1,326 @@
+package gohtml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// preserveElements are the elements whose white space is meaningful
+var preserveElements = map[string]bool{
+	"listing": true, "plaintext": true, "pre": true, "textarea": true,
+}
+
+// inlineElements are the elements laid out in a line by default
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "acronym": true, "audio": true, "b": true,
+	"bdi": true, "bdo": true, "big": true, "br": true, "button": true,
+	"canvas": true, "cite": true, "code": true, "data": true, "del": true,
+	"dfn": true, "em": true, "embed": true, "font": true, "i": true,
+	"iframe": true, "img": true, "input": true, "ins": true, "kbd": true,
+	"label": true, "mark": true, "meter": true, "object": true, "output": true,
+	"picture": true, "progress": true, "q": true, "ruby": true, "rp": true,
+	"rt": true, "s": true, "samp": true, "select": true, "small": true,
+	"span": true, "strike": true, "strong": true, "sub": true, "sup": true,
+	"textarea": true, "time": true, "tt": true, "u": true, "var": true,
+	"video": true, "wbr": true,
+}
+
+// isHTML returns true if n is an element in the HTML namespace
+func isHTML(n *html.Node, tags map[string]bool) bool {
+	return n.Type == html.ElementNode && n.Namespace == "" && tags[n.Data]
+}
+
+// attrName returns the name of the attribute as written in HTML
+func attrName(a html.Attribute) string {
+	if a.Namespace != "" {
+		return a.Namespace + ":" + a.Key
+	}
+	return a.Key
+}
+
+// startTag returns the start tag of n.
+// a foreign element that has no child is self-closing
+func startTag(n *html.Node) string {
+	var b strings.Builder
+	b.WriteString("<" + n.Data)
+	for _, a := range n.Attr {
+		b.WriteString(" " + attrName(a) + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	if n.Namespace != "" && n.FirstChild == nil {
+		b.WriteString("/")
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// endTag returns the end tag of n, or empty string if n does not need it
+func endTag(n *html.Node) string {
+	if (n.Namespace == "" && voidElements[n.Data]) || (n.Namespace != "" && n.FirstChild == nil) {
+		return ""
+	}
+	return "</" + n.Data + ">"
+}
+
+// renderString renders n by html.Render
+func renderString(n *html.Node) string {
+	var b strings.Builder
+	html.Render(&b, n)
+	return b.String()
+}
+
+// VoidStyle is the style of the void element tags
+type VoidStyle int
+
+const (
+	// VoidSlash writes void elements as <br/> like html.Render
+	VoidSlash VoidStyle = iota
+	// VoidNoSlash writes void elements as <br>
+	VoidNoSlash
+	// VoidSpaceSlash writes void elements as <br />
+	VoidSpaceSlash
+)
+
+// QuoteStyle is the style of quoting attribute values
+type QuoteStyle int
+
+const (
+	// QuoteDouble quotes attribute values with double quotes
+	QuoteDouble QuoteStyle = iota
+	// QuoteSingle quotes attribute values with single quotes
+	QuoteSingle
+	// QuoteMinimal does not quote attribute values if it is not needed,
+	// and otherwise quotes with the quote that makes fewer escapes
+	QuoteMinimal
+)
+
+// EntityPolicy decides which characters are escaped
+type EntityPolicy int
+
+const (
+	// EntityDefault escapes characters like html.Render does,
+	// & ' < > " and carriage return
+	EntityDefault EntityPolicy = iota
+	// EntityMinimal escapes only & and < in text, and & and
+	// the quote character in attribute values
+	EntityMinimal
+	// EntityNamed escapes like EntityDefault and uses named character
+	// references for the non-ASCII characters which have a name
+	EntityNamed
+	// EntityASCII escapes like EntityDefault and uses numeric character
+	// references for all of the non-ASCII characters
+	EntityASCII
+)
+
+// RenderOptions is the options for rendering HTML.
+// the zero value renders the same as html.Render
+type RenderOptions struct {
+	// VoidStyle is the style of the void element tags
+	VoidStyle VoidStyle
+	// SortAttributes writes attributes in alphabetical
+	// order instead of the original order
+	SortAttributes bool
+	// Quote is the style of quoting attribute values
+	Quote QuoteStyle
+	// Entities decides which characters are escaped.
+	// the text of raw text elements such as <script> is never escaped
+	Entities EntityPolicy
+}
+
+// Render writes the outer HTML of the element to w.
+// if opts is nil, it is the same as html.Render
+func (e Element) Render(w io.Writer, opts *RenderOptions) error {
+	return render(w, e.Node, opts)
+}
+
+// OuterHTMLWith returns the outer HTML rendered with the options
+func (e Element) OuterHTMLWith(opts *RenderOptions) string {
+	var b strings.Builder
+	render(&b, e.Node, opts)
+	return b.String()
+}
+
+// InnerHTMLWith returns the inner HTML rendered with the options
+func (e Element) InnerHTMLWith(opts *RenderOptions) string {
+	var b strings.Builder
+	for c := e.Node.FirstChild; c != nil; c = c.NextSibling {
+		if err := render(&b, c, opts); err != nil {
+			break
+		}
+	}
+	return b.String()
+}
+
+// Render writes the whole document to w with the options
+func (d Document) Render(w io.Writer, opts *RenderOptions) error {
+	return render(w, d.Node, opts)
+}
+
+// WriteTo writes the whole document to w, it implements io.WriterTo
+func (d Document) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	err := html.Render(cw, d.Node)
+	return cw.n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func render(w io.Writer, n *html.Node, opts *RenderOptions) error {
+	if opts == nil {
+		return html.Render(w, n)
+	}
+	r := &renderer{opts: *opts}
+	err := r.node(n)
+	if err == errPlaintext {
+		err = nil
+	}
+	if _, werr := io.WriteString(w, r.b.String()); err == nil {
+		err = werr
+	}
+	return err
+}
+
+var errPlaintext = errors.New("gohtml: <plaintext> must be the last")
+
+type renderer struct {
+	opts RenderOptions
+	b    strings.Builder
+}
+
+func (r *renderer) node(n *html.Node) error {
+	switch n.Type {
+	case html.ErrorNode:
+		return errors.New("gohtml: cannot render an ErrorNode node")
+	case html.TextNode:
+		r.b.WriteString(r.escape(n.Data, 0))
+		return nil
+	case html.DocumentNode:
+		return r.children(n)
+	case html.CommentNode, html.DoctypeNode, html.RawNode:
+		return html.Render(&r.b, n)
+	}
+
+	r.b.WriteString("<" + n.Data)
+	attrs := n.Attr
+	if r.opts.SortAttributes {
+		attrs = append([]html.Attribute(nil), attrs...)
+		sort.SliceStable(attrs, func(i, j int) bool {
+			return attrName(attrs[i]) < attrName(attrs[j])
+		})
+	}
+	for _, a := range attrs {
+		r.b.WriteString(" " + attrName(a) + "=" + r.quote(a.Val))
+	}
+
+	if voidElements[n.Data] {
+		if n.FirstChild != nil {
+			return fmt.Errorf("gohtml: void element <%s> has child nodes", n.Data)
+		}
+		switch r.opts.VoidStyle {
+		case VoidNoSlash:
+			r.b.WriteString(">")
+		case VoidSpaceSlash:
+			r.b.WriteString(" />")
+		default:
+			r.b.WriteString("/>")
+		}
+		return nil
+	}
+	r.b.WriteString(">")
+
+	// the first newline of <pre> is dropped by the parser
+	if isHTML(n, preserveElements) && n.FirstChild != nil &&
+		n.FirstChild.Type == html.TextNode && strings.HasPrefix(n.FirstChild.Data, "\n") {
+		r.b.WriteString("\n")
+	}
+
+	if isHTML(n, rawTextElements) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				r.b.WriteString(c.Data)
+			} else if err := r.node(c); err != nil {
+				return err
+			}
+		}
+		if n.Data == "plaintext" {
+			return errPlaintext
+		}
+	} else if err := r.children(n); err != nil {
+		return err
+	}
+
+	r.b.WriteString("</" + n.Data + ">")
+	return nil
+}
+
+func (r *renderer) children(n *html.Node) error {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if err := r.node(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quote returns the quoted and escaped attribute value
+func (r *renderer) quote(s string) string {
+	switch r.opts.Quote {
+	case QuoteSingle:
+		return "'" + r.escape(s, '\'') + "'"
+	case QuoteMinimal:
+		if s != "" && !strings.ContainsAny(s, " \t\n\r\f\"'=<>`") {
+			return r.escape(s, ' ')
+		}
+		if strings.Contains(s, `"`) && !strings.Contains(s, "'") {
+			return "'" + r.escape(s, '\'') + "'"
+		}
+	}
+	return `"` + r.escape(s, '"') + `"`
+}
+
+// escape escapes s by the entity policy. q is the quote character
+// around the attribute value, or 0 for text
+func (r *renderer) escape(s string, q rune) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c == '&':
+			b.WriteString("&amp;")
+		case c == '<' && (q == 0 || r.opts.Entities != EntityMinimal):
+			b.WriteString("&lt;")
+		case r.opts.Entities == EntityMinimal:
+			if q != 0 && c == q {
+				b.WriteString("&#" + strconv.Itoa(int(c)) + ";")
+			} else {
+				b.WriteRune(c)
+			}
+		case c == '>':
+			b.WriteString("&gt;")
+		case c == '"' && r.opts.Entities == EntityNamed:
+			b.WriteString("&quot;")
+		case c == '"' || c == '\'' || c == '\r':
+			b.WriteString("&#" + strconv.Itoa(int(c)) + ";")
+		case c > 0x7f && r.opts.Entities == EntityNamed && namedEntities[c] != "":
+			b.WriteString("&" + namedEntities[c] + ";")
+		case c > 0x7f && r.opts.Entities == EntityASCII:
+			b.WriteString("&#x" + strconv.FormatInt(int64(c), 16) + ";")
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package gohtml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderOptions(t *testing.T) {
+	s := `<div title='a "b"' id="x" class="c"><br>caf&eacute; &amp; <i>&lt;tag&gt;</i>&nbsp;it's<script>if (a < b) {}</script><svg><path d="M0"></path></svg></div>`
+	doc, _ := Parse(strings.NewReader(s))
+	div := doc.QuerySelector("div")
+
+	tests := []struct {
+		opts   *RenderOptions
+		expect string
+	}{
+		{
+			nil,
+			div.OuterHTML(),
+		},
+		{
+			&RenderOptions{},
+			div.OuterHTML(),
+		},
+		{
+			&RenderOptions{VoidStyle: VoidNoSlash, SortAttributes: true, Quote: QuoteMinimal, Entities: EntityMinimal},
+			`<div class=c id=x title='a "b"'><br>café &amp; <i>&lt;tag></i>` + " " + `it's<script>if (a < b) {}</script><svg><path d=M0></path></svg></div>`,
+		},
+		{
+			&RenderOptions{VoidStyle: VoidSpaceSlash, Quote: QuoteSingle, Entities: EntityNamed},
+			`<div title='a &quot;b&quot;' id='x' class='c'><br />caf&eacute; &amp; <i>&lt;tag&gt;</i>&nbsp;it&#39;s<script>if (a < b) {}</script><svg><path d='M0'></path></svg></div>`,
+		},
+		{
+			&RenderOptions{Entities: EntityASCII},
+			`<div title="a &#34;b&#34;" id="x" class="c"><br/>caf&#xe9; &amp; <i>&lt;tag&gt;</i>&#xa0;it&#39;s<script>if (a < b) {}</script><svg><path d="M0"></path></svg></div>`,
+		},
+	}
+	for i, test := range tests {
+		if actual := div.OuterHTMLWith(test.opts); actual != test.expect {
+			t.Errorf("\n%d: got : %s\nwant: %s\n", i, actual, test.expect)
+		}
+
+		// must be the same tree after parsing again
+		again, _ := Parse(strings.NewReader(div.OuterHTMLWith(test.opts)))
+		sorted := &RenderOptions{SortAttributes: true}
+		if a, b := again.QuerySelector("div").OuterHTMLWith(sorted), div.OuterHTMLWith(sorted); a != b {
+			t.Errorf("\n%d: parsed again: %s\nwant: %s\n", i, a, b)
+		}
+	}
+
+	inner := div.InnerHTMLWith(&RenderOptions{VoidStyle: VoidNoSlash})
+	if !strings.HasPrefix(inner, "<br>café") {
+		t.Errorf("\ngot : %s\n", inner)
+	}
+}
+
+func TestDocumentWriteTo(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(test_html))
+	var buf bytes.Buffer
+	n, err := doc.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("\ngot : %d, want: %d\n", n, buf.Len())
+	}
+
+	var expect bytes.Buffer
+	doc.Render(&expect, nil)
+	if buf.String() != expect.String() {
+		t.Errorf("\ngot : %s\nwant: %s\n", buf.String(), expect.String())
+	}
+}
@@ -0,0 +1,331 @@
+package gohtml
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/saihon/gohtml/attr"
+	"github.com/saihon/gohtml/find"
+)
+
+// URLKind is the kind of the place the URL is in
+type URLKind int
+
+const (
+	// URLAttribute is the attribute that is a URL, like href or src
+	URLAttribute URLKind = iota
+	// URLSrcset is a candidate of srcset
+	URLSrcset
+	// URLRefresh is the URL in the content of <meta http-equiv="refresh">
+	URLRefresh
+	// URLStyle is url() or @import in the style attribute or <style>
+	URLStyle
+)
+
+// URLContext is the context of the URL that RewriteURLs visits
+type URLContext struct {
+	Element *Element
+	// Attribute is the name of the attribute,
+	// empty for the text of <style>
+	Attribute string
+	Kind      URLKind
+	// Raw is the URL as it is written
+	Raw string
+}
+
+// URLRewriter returns the URL that replaces u, or nil to keep it.
+// u is resolved against the base URL of the document
+type URLRewriter func(u *url.URL, ctx URLContext) (*url.URL, error)
+
+// urlAttributes is the attributes that are a URL and the elements
+// that have them. nil means any element
+var urlAttributes = map[string][]atom.Atom{
+	"href":       nil,
+	"src":        nil,
+	"action":     {atom.Form},
+	"formaction": {atom.Button, atom.Input},
+	"poster":     {atom.Video},
+	"cite":       {atom.Blockquote, atom.Q, atom.Del, atom.Ins},
+	"data":       {atom.Object},
+	"background": {atom.Body, atom.Table, atom.Thead, atom.Tbody, atom.Tfoot, atom.Tr, atom.Td, atom.Th},
+}
+
+func isURLAttribute(n *html.Node, key string) bool {
+	atoms, ok := urlAttributes[key]
+	if !ok {
+		return false
+	}
+	if atoms == nil {
+		return true
+	}
+	for _, a := range atoms {
+		if n.DataAtom == a {
+			return true
+		}
+	}
+	return false
+}
+
+// RewriteURLs calls fn for every URL in the document, the URL
+// attributes including xlink:href, the candidates of srcset and
+// imagesrcset, the URL of <meta http-equiv="refresh"> and url() and
+// @import in the style attributes and <style>, then replaces the URL
+// by the returned one. the URLs that cannot be parsed and the empty
+// URLs are skipped. the URLs are replaced after all URLs are visited,
+// so if fn returns an error, the document is not changed and the error
+// is returned. the rewriting is not recorded to the history unless it
+// is in the transaction of the caller
+func (d Document) RewriteURLs(fn URLRewriter) error {
+	r := &urlRewriter{fn: fn, base: baseURI(d.Node), doc: documentURL(d.Node)}
+	if err := r.walk(d.Node); err != nil {
+		return err
+	}
+	for _, c := range r.changes {
+		if c.n.Type == html.TextNode {
+			mutateData(c.n, c.value)
+		} else {
+			(&Element{c.n}).SetAttributeNS(c.namespace, c.key, c.value)
+		}
+	}
+	return nil
+}
+
+type urlRewriter struct {
+	fn   URLRewriter
+	base *url.URL
+	// doc is the URL of the document that <base> is resolved against
+	doc *url.URL
+	// changes are applied after the walk
+	changes []urlChange
+}
+
+// urlChange is the new value of the attribute, or the text of <style>
+type urlChange struct {
+	n              *html.Node
+	namespace, key string
+	value          string
+}
+
+func (r *urlRewriter) walk(root *html.Node) error {
+	elements := find.All(root, func(n *html.Node) bool {
+		return n.Type == html.ElementNode
+	})
+	for _, n := range elements {
+		if err := r.element(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *urlRewriter) element(n *html.Node) error {
+	e := &Element{n}
+	for i := 0; i < len(n.Attr); i++ {
+		a := n.Attr[i]
+		if a.Namespace != "" {
+			// xlink:href of SVG like <use> and <image>
+			if a.Namespace != "xlink" || a.Key != "href" {
+				continue
+			}
+		}
+		ctx := URLContext{Element: e, Attribute: a.Key}
+		if a.Namespace != "" {
+			ctx.Attribute = a.Namespace + ":" + a.Key
+		}
+		var (
+			v       string
+			changed bool
+			err     error
+		)
+		switch {
+		case a.Namespace != "":
+			v, changed, err = r.rewrite(a.Val, ctx)
+		case a.Key == "srcset" && (n.DataAtom == atom.Img || n.DataAtom == atom.Source),
+			a.Key == "imagesrcset" && n.DataAtom == atom.Link:
+			ctx.Kind = URLSrcset
+			v, changed, err = r.srcset(a.Val, ctx)
+		case a.Key == "content" && n.DataAtom == atom.Meta && strings.EqualFold(attr.Get(n, "http-equiv"), "refresh"):
+			ctx.Kind = URLRefresh
+			v, changed, err = r.refresh(a.Val, ctx)
+		case a.Key == "style":
+			ctx.Kind = URLStyle
+			v, changed, err = r.css(a.Val, ctx)
+		case isURLAttribute(n, a.Key):
+			v, changed, err = r.rewrite(a.Val, ctx)
+		}
+		if err != nil {
+			return err
+		}
+		if changed {
+			r.changes = append(r.changes, urlChange{n, a.Namespace, a.Key, v})
+		}
+	}
+
+	if n.DataAtom == atom.Style {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.TextNode {
+				continue
+			}
+			v, changed, err := r.css(c.Data, URLContext{Element: e, Kind: URLStyle})
+			if err != nil {
+				return err
+			}
+			if changed {
+				r.changes = append(r.changes, urlChange{n: c, value: v})
+			}
+		}
+	}
+	return nil
+}
+
+// rewrite calls fn for the URL and returns the new URL
+func (r *urlRewriter) rewrite(raw string, ctx URLContext) (string, bool, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return raw, false, nil
+	}
+	base := r.base
+	if ctx.Element.Node.DataAtom == atom.Base {
+		base = r.doc
+	}
+	u, err := resolveURL(base, s)
+	if err != nil {
+		return raw, false, nil
+	}
+	ctx.Raw = raw
+	u, err = r.fn(u, ctx)
+	if err != nil || u == nil {
+		return raw, false, err
+	}
+	return u.String(), true, nil
+}
+
+func (r *urlRewriter) srcset(s string, ctx URLContext) (string, bool, error) {
+	items := splitSrcset(s)
+	changed := false
+	for i := range items {
+		v, ok, err := r.rewrite(items[i].url, ctx)
+		if err != nil {
+			return s, false, err
+		}
+		if ok {
+			// the comma would split the candidate
+			items[i].url = strings.ReplaceAll(v, ",", "%2C")
+			changed = true
+		}
+	}
+	if !changed {
+		return s, false, nil
+	}
+	return formatSrcset(items), true, nil
+}
+
+// refreshURL returns the range of the URL in the content of
+// <meta http-equiv="refresh">, or -1 if it has no URL
+func refreshURL(s string) (int, int) {
+	i := 0
+	skipSpace := func() {
+		for i < len(s) && isSrcsetSpace(s[i]) {
+			i++
+		}
+	}
+	skipSpace()
+	start := i
+	for i < len(s) && ('0' <= s[i] && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	if i == start {
+		return -1, -1
+	}
+	if i < len(s) && !isSrcsetSpace(s[i]) && s[i] != ';' && s[i] != ',' {
+		return -1, -1
+	}
+	skipSpace()
+	if i < len(s) && (s[i] == ';' || s[i] == ',') {
+		i++
+	}
+	skipSpace()
+	if i+3 <= len(s) && strings.EqualFold(s[i:i+3], "url") {
+		j := i + 3
+		for j < len(s) && isSrcsetSpace(s[j]) {
+			j++
+		}
+		if j < len(s) && s[j] == '=' {
+			i = j + 1
+			skipSpace()
+		}
+	}
+	end := len(s)
+	if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+		if k := strings.IndexByte(s[i+1:], s[i]); k >= 0 {
+			end = i + 1 + k
+		}
+		i++
+	}
+	if i >= end {
+		return -1, -1
+	}
+	return i, end
+}
+
+func (r *urlRewriter) refresh(s string, ctx URLContext) (string, bool, error) {
+	start, end := refreshURL(s)
+	if start < 0 {
+		return s, false, nil
+	}
+	v, ok, err := r.rewrite(s[start:end], ctx)
+	if err != nil || !ok {
+		return s, false, err
+	}
+	return s[:start] + v + s[end:], true, nil
+}
+
+// reCSSURL matches url() and @import with the string of CSS
+var reCSSURL = regexp.MustCompile(`(?i)url\(\s*(?:"([^"]*)"|'([^']*)'|([^)"'\s]+))\s*\)|@import\s+(?:"([^"]*)"|'([^']*)')`)
+
+func (r *urlRewriter) css(s string, ctx URLContext) (string, bool, error) {
+	var b strings.Builder
+	last := 0
+	changed := false
+	for _, m := range reCSSURL.FindAllStringSubmatchIndex(s, -1) {
+		// the group that matched is the URL. 1 and 4 are quoted by '"',
+		// 2 and 5 are quoted by "'" and 3 is not quoted
+		for g := 1; g <= 5; g++ {
+			start, end := m[2*g], m[2*g+1]
+			if start < 0 {
+				continue
+			}
+			v, ok, err := r.rewrite(s[start:end], ctx)
+			if err != nil {
+				return s, false, err
+			}
+			if !ok {
+				break
+			}
+			switch g {
+			case 1, 4:
+				v = strings.ReplaceAll(v, `"`, "%22")
+			case 2, 5:
+				v = strings.ReplaceAll(v, "'", "%27")
+			case 3:
+				if strings.ContainsAny(v, "()'\" \t\n\r\f") {
+					v = `"` + strings.ReplaceAll(v, `"`, "%22") + `"`
+				}
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(v)
+			last = end
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return s, false, nil
+	}
+	b.WriteString(s[last:])
+	return b.String(), true, nil
+}
@@ -0,0 +1,160 @@
+package gohtml
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testRewrite = `<html><head>
+<base href="https://cdn.test/assets/">
+<meta http-equiv="refresh" content="5; URL='next.html'">
+<style>body { background: url("bg.png") } @import 'theme.css';</style>
+<link rel="stylesheet" href="a.css">
+<link rel="preload" as="image" href="l.png" imagesrcset="l-1x.png 1x, l-2x.png 2x">
+</head><body background="b.png">
+<a href="/page">x</a><a href="">empty</a>
+<img src="i.png" srcset="i-1x.png 1x, i,2.png 2x">
+<form action="post"><button formaction="alt">b</button></form>
+<video poster="p.jpg"></video><blockquote cite="c.html"></blockquote><object data="d.swf"></object>
+<div style="background:url(x.png)"></div><p data="not-a-url"></p>
+<svg><image xlink:href="s.png"></image><use xlink:href="#icon" href="u.svg"></use></svg>
+</body></html>`
+
+func TestRewriteURLs(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(testRewrite))
+	u, _ := url.Parse("https://site.test/dir/")
+	doc.SetURL(u)
+	original := doc.DocumentElement().OuterHTML()
+
+	var visited []string
+	err := doc.RewriteURLs(func(u *url.URL, ctx URLContext) (*url.URL, error) {
+		visited = append(visited, fmt.Sprintf("%s %s %d %s", ctx.Element.Node.Data, ctx.Attribute, ctx.Kind, u))
+		v := *u
+		v.Host = "proxy.test"
+		return &v, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		"base href 0 https://cdn.test/assets/",
+		"meta content 2 https://cdn.test/assets/next.html",
+		"style  3 https://cdn.test/assets/bg.png",
+		"style  3 https://cdn.test/assets/theme.css",
+		"link href 0 https://cdn.test/assets/a.css",
+		"link href 0 https://cdn.test/assets/l.png",
+		"link imagesrcset 1 https://cdn.test/assets/l-1x.png",
+		"link imagesrcset 1 https://cdn.test/assets/l-2x.png",
+		"body background 0 https://cdn.test/assets/b.png",
+		"a href 0 https://cdn.test/page",
+		"img src 0 https://cdn.test/assets/i.png",
+		"img srcset 1 https://cdn.test/assets/i-1x.png",
+		"img srcset 1 https://cdn.test/assets/i,2.png",
+		"form action 0 https://cdn.test/assets/post",
+		"button formaction 0 https://cdn.test/assets/alt",
+		"video poster 0 https://cdn.test/assets/p.jpg",
+		"blockquote cite 0 https://cdn.test/assets/c.html",
+		"object data 0 https://cdn.test/assets/d.swf",
+		"div style 3 https://cdn.test/assets/x.png",
+		"image xlink:href 0 https://cdn.test/assets/s.png",
+		"use xlink:href 0 https://cdn.test/assets/#icon",
+		"use href 0 https://cdn.test/assets/u.svg",
+	}
+	if actual, want := strings.Join(visited, "\n"), strings.Join(expect, "\n"); actual != want {
+		t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, want)
+	}
+
+	tests := []struct {
+		actual, expect string
+	}{
+		{doc.QuerySelector("meta").GetAttribute("content"), "5; URL='https://proxy.test/assets/next.html'"},
+		{doc.QuerySelector("style").TextContent(), `body { background: url("https://proxy.test/assets/bg.png") } @import 'https://proxy.test/assets/theme.css';`},
+		{doc.QuerySelector("img").GetAttribute("srcset"), "https://proxy.test/assets/i-1x.png 1x, https://proxy.test/assets/i%2C2.png 2x"},
+		{doc.QuerySelector("div").GetAttribute("style"), "background:url(https://proxy.test/assets/x.png)"},
+		{doc.QuerySelector("a").GetAttribute("href"), "https://proxy.test/page"},
+		{doc.QuerySelector("p").GetAttribute("data"), "not-a-url"},
+		{doc.QuerySelectorAll("link").Get(1).GetAttribute("imagesrcset"), "https://proxy.test/assets/l-1x.png 1x, https://proxy.test/assets/l-2x.png 2x"},
+		{doc.QuerySelector("image").GetAttributeNS("xlink", "href"), "https://proxy.test/assets/s.png"},
+		{doc.QuerySelector("use").GetAttributeNS("xlink", "href"), "https://proxy.test/assets/#icon"},
+		{doc.QuerySelector("use").GetAttributeNS("", "href"), "https://proxy.test/assets/u.svg"},
+	}
+	for i, tt := range tests {
+		if tt.actual != tt.expect {
+			t.Errorf("\n%d: got : %s, want: %s\n", i, tt.actual, tt.expect)
+		}
+	}
+
+	if doc.CanUndo() {
+		t.Errorf("\nthe rewriting must not be recorded\n")
+	}
+	doc.Release()
+
+	// the rewriting in the transaction can be undone
+	doc, _ = Parse(strings.NewReader(testRewrite))
+	defer doc.Release()
+	tx := doc.Begin()
+	doc.RewriteURLs(func(u *url.URL, ctx URLContext) (*url.URL, error) {
+		return url.Parse("/rewritten")
+	})
+	tx.Commit()
+	if err := doc.Undo(); err != nil || doc.DocumentElement().OuterHTML() != original {
+		t.Errorf("\nthe rewriting must be undone: %v\n", err)
+	}
+}
+
+func TestRewriteURLsError(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(testRewrite))
+	original := doc.DocumentElement().OuterHTML()
+
+	errStop := errors.New("stop")
+	n := 0
+	err := doc.RewriteURLs(func(u *url.URL, ctx URLContext) (*url.URL, error) {
+		if n++; n == 5 {
+			return nil, errStop
+		}
+		return url.Parse("/rewritten")
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("\ngot : %v, want: %v\n", err, errStop)
+	}
+	if doc.DocumentElement().OuterHTML() != original || doc.CanUndo() {
+		t.Errorf("\nthe document must not be changed\n")
+	}
+
+	// nil keeps the URL
+	doc.RewriteURLs(func(u *url.URL, ctx URLContext) (*url.URL, error) {
+		return nil, nil
+	})
+	if doc.DocumentElement().OuterHTML() != original {
+		t.Errorf("\nthe URLs must be kept\n")
+	}
+	if lookupDocData(doc.Node) != nil {
+		t.Errorf("\nno state must be kept for the document\n")
+	}
+}
+
+func TestRefreshURL(t *testing.T) {
+	tests := []struct {
+		input, expect string
+	}{
+		{"0; url=a.html", "a.html"},
+		{"0;URL = 'a b.html' trailing", "a b.html"},
+		{`3, "a.html"`, "a.html"},
+		{"1.5 a.html", "a.html"},
+		{"5", ""},
+		{"x; url=a.html", ""},
+		{"5; url=", ""},
+	}
+	for _, tt := range tests {
+		actual := ""
+		if start, end := refreshURL(tt.input); start >= 0 {
+			actual = tt.input[start:end]
+		}
+		if actual != tt.expect {
+			t.Errorf("\n%q: got : %q, want: %q\n", tt.input, actual, tt.expect)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+// Package sanitize removes the elements, attributes and URLs that are not
+// allowed by a policy from gohtml documents, to make user-submitted HTML safe
+package sanitize
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/saihon/gohtml"
+	"github.com/saihon/gohtml/attr"
+)
+
+// Policy is the allowlist of the sanitizer. anything not allowed is removed
+type Policy struct {
+	// Elements is the allowed elements and the attributes allowed on each.
+	// the elements in SVG or MathML are keyed with the namespace, e.g. "svg:path"
+	Elements map[string][]string
+	// GlobalAttributes is the attributes allowed on every allowed element
+	GlobalAttributes []string
+	// AllowDataAttributes allows the attributes start with "data-"
+	AllowDataAttributes bool
+	// URLSchemes is the allowed schemes of URLs for each attribute.
+	// the key "*" is used for the attributes which are not in the map
+	URLSchemes map[string][]string
+	// AllowRelativeURLs allows URLs that have no scheme
+	AllowRelativeURLs bool
+	// StyleProperties is the allowed CSS properties in the style attribute
+	StyleProperties []string
+	// AllowComments keeps comments
+	AllowComments bool
+	// EscapeDisallowed writes the tags of the disallowed elements as the
+	// text instead of removing them. the contents are sanitized either way
+	EscapeDisallowed bool
+	// RequireNoFollow adds "nofollow" to the rel attribute of links
+	RequireNoFollow bool
+	// TargetBlank sets target="_blank" to links. a link that opens in
+	// a new window always gets "noopener noreferrer" in the rel attribute
+	TargetBlank bool
+}
+
+// UGCPolicy returns a policy allows the common formatting of user generated content
+func UGCPolicy() *Policy {
+	p := &Policy{
+		Elements:          map[string][]string{},
+		GlobalAttributes:  []string{"title", "lang", "dir", "style"},
+		URLSchemes:        map[string][]string{"*": {"http", "https"}, "href": {"http", "https", "mailto"}},
+		AllowRelativeURLs: true,
+		StyleProperties:   []string{"color", "background-color", "text-align", "text-decoration", "font-weight", "font-style"},
+		RequireNoFollow:   true,
+	}
+	for _, tag := range []string{
+		"abbr", "b", "blockquote", "br", "caption", "cite", "code", "dd", "del",
+		"div", "dl", "dt", "em", "figcaption", "figure", "h1", "h2", "h3", "h4",
+		"h5", "h6", "hr", "i", "ins", "kbd", "li", "mark", "ol", "p", "pre", "q",
+		"s", "samp", "small", "span", "strong", "sub", "sup", "table", "tbody",
+		"td", "tfoot", "th", "thead", "tr", "u", "ul", "var",
+	} {
+		p.Elements[tag] = nil
+	}
+	p.Elements["a"] = []string{"href", "rel", "target"}
+	p.Elements["img"] = []string{"src", "srcset", "alt", "width", "height"}
+	p.Elements["td"] = []string{"colspan", "rowspan"}
+	p.Elements["th"] = []string{"colspan", "rowspan", "scope"}
+	p.Elements["ol"] = []string{"start", "reversed"}
+	p.Elements["blockquote"] = []string{"cite"}
+	p.Elements["q"] = []string{"cite"}
+	return p
+}
+
+// StrictPolicy returns a policy that allows nothing but text
+func StrictPolicy() *Policy {
+	return &Policy{}
+}
+
+// dropElements are removed with the contents when these are not allowed
+var dropElements = map[string]bool{
+	"embed": true, "frame": true, "frameset": true, "iframe": true,
+	"noembed": true, "noframes": true, "noscript": true, "object": true,
+	"plaintext": true, "script": true, "style": true, "template": true,
+	"title": true, "xmp": true, "textarea": true, "select": true,
+}
+
+// urlAttributes are the attributes which have URL
+var urlAttributes = map[string]bool{
+	"action": true, "background": true, "cite": true, "codebase": true,
+	"data": true, "formaction": true, "href": true, "icon": true,
+	"longdesc": true, "manifest": true, "poster": true, "src": true,
+	"usemap": true, "xlink:href": true,
+}
+
+// Document sanitizes the whole document in place.
+// <html>, <head> and <body> are kept but their attributes are sanitized
+func Document(doc *gohtml.Document, p *Policy) {
+	p.sanitizeChildren(doc.Node)
+}
+
+// Element sanitizes the descendants of the element in place
+func Element(e *gohtml.Element, p *Policy) {
+	p.sanitizeChildren(e.Node)
+}
+
+// HTML sanitizes the HTML fragment parsed in the context of <body>.
+// the result is sanitized again until it is stable, so that
+// parsing it again never makes a different tree
+func HTML(s string, p *Policy) (string, error) {
+	for i := 0; i < 4; i++ {
+		out, err := p.fragment(s)
+		if err != nil {
+			return "", err
+		}
+		if out == s {
+			return out, nil
+		}
+		s = out
+	}
+	// not stable, give up the markup
+	return html.EscapeString(s), nil
+}
+
+func (p *Policy) fragment(s string) (string, error) {
+	body := gohtml.CreateElement("body")
+	nodes, err := html.ParseFragment(strings.NewReader(s), body.Node)
+	if err != nil {
+		return "", err
+	}
+	for _, n := range nodes {
+		body.AppendChild(&gohtml.Element{Node: n})
+	}
+	p.sanitizeChildren(body.Node)
+	return body.InnerHTML(), nil
+}
+
+func (p *Policy) sanitizeChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		p.sanitize(n, c)
+		c = next
+	}
+}
+
+func (p *Policy) sanitize(parent, n *html.Node) {
+	pe := &gohtml.Element{Node: parent}
+	e := &gohtml.Element{Node: n}
+
+	switch n.Type {
+	case html.TextNode, html.DoctypeNode:
+		return
+	case html.CommentNode:
+		if !p.AllowComments || strings.Contains(n.Data, "<") || strings.Contains(n.Data, ">") {
+			pe.RemoveChild(e)
+		}
+		return
+	case html.ElementNode:
+	default:
+		pe.RemoveChild(e)
+		return
+	}
+
+	if n.Namespace == "" && (n.Data == "html" || n.Data == "head" || n.Data == "body") {
+		p.sanitizeAttributes(n)
+		p.sanitizeChildren(n)
+		return
+	}
+
+	name := n.Data
+	if n.Namespace != "" {
+		name = n.Namespace + ":" + n.Data
+	}
+	if _, ok := p.Elements[name]; !ok {
+		p.disallowed(pe, e)
+		return
+	}
+
+	if n.Namespace == "" && n.Data == "noscript" {
+		p.noscript(e)
+	} else {
+		p.sanitizeChildren(n)
+	}
+	p.sanitizeAttributes(n)
+	p.links(n)
+}
+
+// disallowed removes or escapes the element
+func (p *Policy) disallowed(parent, e *gohtml.Element) {
+	n := e.Node
+	if n.Namespace != "" || dropElements[n.Data] {
+		parent.RemoveChild(e)
+		return
+	}
+
+	p.sanitizeChildren(n)
+	if p.EscapeDisallowed {
+		start := gohtml.CreateTextNode(outerTag(n))
+		parent.InsertBefore(start, e)
+	}
+	for c := n.FirstChild; c != nil; c = n.FirstChild {
+		child := &gohtml.Element{Node: c}
+		e.RemoveChild(child)
+		parent.InsertBefore(child, e)
+	}
+	if p.EscapeDisallowed && !voidElement(n.Data) {
+		end := gohtml.CreateTextNode("</" + n.Data + ">")
+		parent.InsertBefore(end, e)
+	}
+	parent.RemoveChild(e)
+}
+
+// outerTag returns the start tag of n
+func outerTag(n *html.Node) string {
+	var b strings.Builder
+	b.WriteString("<" + n.Data)
+	for _, a := range n.Attr {
+		b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+func voidElement(tag string) bool {
+	switch tag {
+	case "area", "base", "br", "col", "embed", "hr", "img", "input",
+		"keygen", "link", "meta", "param", "source", "track", "wbr":
+		return true
+	}
+	return false
+}
+
+// noscript sanitizes the raw text of <noscript> as HTML,
+// it is parsed as HTML when the scripting is disabled
+func (p *Policy) noscript(e *gohtml.Element) {
+	text := e.TextContent()
+	out, err := p.fragment(text)
+	if err != nil || strings.Contains(strings.ToLower(out), "</noscript") {
+		out = ""
+	}
+	e.TextContent(out)
+}
+
+// allowedAttribute returns true if the attribute is allowed on the tag.
+// the names are compared case-insensitively for the camel case SVG attributes
+func (p *Policy) allowedAttribute(tag string, a html.Attribute) bool {
+	key := a.Key
+	if a.Namespace != "" {
+		key = a.Namespace + ":" + a.Key
+	}
+	if strings.HasPrefix(key, "data-") && p.AllowDataAttributes {
+		return true
+	}
+	for _, k := range p.Elements[tag] {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	for _, k := range p.GlobalAttributes {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) sanitizeAttributes(n *html.Node) {
+	e := &gohtml.Element{Node: n}
+	tag := n.Data
+	if n.Namespace != "" {
+		tag = n.Namespace + ":" + n.Data
+	}
+
+	for _, a := range append([]html.Attribute(nil), n.Attr...) {
+		key := a.Key
+		if a.Namespace != "" {
+			key = a.Namespace + ":" + a.Key
+		}
+
+		var (
+			value string
+			ok    = p.allowedAttribute(tag, a)
+		)
+		switch {
+		case !ok:
+		case key == "style":
+			value = p.Style(a.Val)
+			ok = value != ""
+		case key == "srcset":
+			value, ok = p.srcset(a.Val)
+		case urlAttributes[key]:
+			value, ok = a.Val, p.URL(key, a.Val)
+		default:
+			value = a.Val
+		}
+
+		if !ok {
+			e.RemoveAttributeNode(a)
+		} else if value != a.Val {
+			a.Val = value
+			e.SetAttributeNodeNS(a)
+		}
+	}
+}
+
+// URL returns true if the URL value of the attribute is allowed.
+// the white space and the control characters ignored by browsers
+// are removed before checking the scheme
+func (p *Policy) URL(key, value string) bool {
+	u := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+
+	i := strings.IndexAny(u, ":/?#")
+	if i < 0 || u[i] != ':' {
+		return p.AllowRelativeURLs
+	}
+	scheme := strings.ToLower(u[:i])
+
+	schemes, ok := p.URLSchemes[key]
+	if !ok {
+		schemes = p.URLSchemes["*"]
+	}
+	for _, s := range schemes {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// srcset removes the candidates that have not allowed URL
+func (p *Policy) srcset(value string) (string, bool) {
+	var kept []string
+	for _, c := range strings.Split(value, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		u := strings.Fields(c)[0]
+		if p.URL("srcset", u) {
+			kept = append(kept, c)
+		}
+	}
+	return strings.Join(kept, ", "), len(kept) > 0
+}
+
+// Style returns the declarations of the allowed properties in the style
+func (p *Policy) Style(value string) string {
+	var kept []string
+	for _, decl := range strings.Split(value, ";") {
+		i := strings.Index(decl, ":")
+		if i < 0 {
+			continue
+		}
+		prop := strings.ToLower(strings.TrimSpace(decl[:i]))
+		val := strings.TrimSpace(decl[i+1:])
+
+		allowed := false
+		for _, s := range p.StyleProperties {
+			if s == prop {
+				allowed = true
+				break
+			}
+		}
+		lower := strings.ToLower(val)
+		if !allowed || val == "" || strings.ContainsAny(val, `\<>"'`) ||
+			strings.Contains(lower, "url(") || strings.Contains(lower, "expression") ||
+			strings.Contains(lower, "javascript:") || strings.Contains(lower, "@import") {
+			continue
+		}
+		kept = append(kept, prop+": "+val)
+	}
+	return strings.Join(kept, "; ")
+}
+
+// links adds rel and target to the links
+func (p *Policy) links(n *html.Node) {
+	if n.Namespace != "" || (n.Data != "a" && n.Data != "area") || !attr.Has(n, "href") {
+		return
+	}
+	e := &gohtml.Element{Node: n}
+
+	rel := strings.Fields(attr.Get(n, "rel"))
+	add := func(v string) {
+		for _, r := range rel {
+			if strings.EqualFold(r, v) {
+				return
+			}
+		}
+		rel = append(rel, v)
+	}
+
+	if p.RequireNoFollow {
+		add("nofollow")
+	}
+	if p.TargetBlank {
+		e.SetAttribute("target", "_blank")
+	}
+	if strings.EqualFold(attr.Get(n, "target"), "_blank") {
+		add("noopener")
+		add("noreferrer")
+	}
+	if len(rel) > 0 {
+		e.SetAttribute("rel", strings.Join(rel, " "))
+	}
+}
@@ -0,0 +1,91 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/saihon/gohtml"
+)
+
+func TestHTML(t *testing.T) {
+	p := UGCPolicy()
+
+	tests := []struct {
+		input, expect string
+	}{
+		{`<p onclick="x()">hello <b>world</b></p>`, `<p>hello <b>world</b></p>`},
+		{`<script>alert(1)</script>text`, `text`},
+		{`<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="java&#x09;script&#58;alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href=" &#14; JaVaScRiPt:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="/path?q=javascript:x">x</a>`, `<a href="/path?q=javascript:x" rel="nofollow">x</a>`},
+		{`<a href="https://example.com" target="_blank">x</a>`, `<a href="https://example.com" target="_blank" rel="nofollow noopener noreferrer">x</a>`},
+		{`<img src="data:text/html,x" alt="a">`, `<img alt="a"/>`},
+		{`<img srcset="a.png 1x, javascript:x 2x">`, `<img srcset="a.png 1x"/>`},
+		{`<span style="color: red; position: fixed; background-color: url(x)">x</span>`, `<span style="color: red">x</span>`},
+		{`<custom><i>x</i></custom>`, `<i>x</i>`},
+		{`<svg><a xlink:href="javascript:x"><text>y</text></a></svg>z`, `z`},
+		{`<math><mi><style><img src=x onerror=alert(1)></style></mi></math>`, ``},
+		{`<template><b>x</b><script>y</script></template>`, ``},
+		{`<noscript><p title="</noscript><img src=x onerror=alert(1)>"></noscript>`, `<img src="x"/>&#34;&gt;`},
+		{`<!-- comment -->x`, `x`},
+	}
+	for i, test := range tests {
+		actual, err := HTML(test.input, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != test.expect {
+			t.Errorf("\n%d: got : %s\nwant: %s\n", i, actual, test.expect)
+		}
+	}
+}
+
+func TestEscapeDisallowed(t *testing.T) {
+	p := UGCPolicy()
+	p.EscapeDisallowed = true
+
+	actual, _ := HTML(`<p><custom id="a">x<b>y</b></custom></p>`, p)
+	expect := `<p>&lt;custom id=&#34;a&#34;&gt;x<b>y</b>&lt;/custom&gt;</p>`
+	if actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+}
+
+func TestForeignElements(t *testing.T) {
+	p := &Policy{
+		Elements: map[string][]string{
+			"svg:svg":    {"viewbox"},
+			"svg:circle": {"r"},
+			"noscript":   nil,
+			"template":   nil,
+			"b":          nil,
+		},
+	}
+
+	tests := []struct {
+		input, expect string
+	}{
+		{`<svg viewBox="0 0 1 1" onload="x"><circle r="1"/><script>x</script></svg>`, `<svg viewBox="0 0 1 1"><circle r="1"></circle></svg>`},
+		{`<circle r="1">x</circle>`, `x`},
+		{`<template><b onclick="x">y</b><i>z</i></template>`, `<template><b>y</b>z</template>`},
+		{`<noscript><b>a</b><i>b</i></noscript>`, `<noscript><b>a</b>b</noscript>`},
+	}
+	for i, test := range tests {
+		actual, _ := HTML(test.input, p)
+		if actual != test.expect {
+			t.Errorf("\n%d: got : %s\nwant: %s\n", i, actual, test.expect)
+		}
+	}
+}
+
+func TestDocument(t *testing.T) {
+	s := `<html onload="x"><head><title>t</title><script>x</script></head><body class="a"><p>hello<iframe src="x"></iframe></p></body></html>`
+	doc, _ := gohtml.Parse(strings.NewReader(s))
+	Document(doc, StrictPolicy())
+
+	expect := `<html><head></head><body>hello</body></html>`
+	if actual := doc.DocumentElement().OuterHTML(); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+}
@@ -0,0 +1,247 @@
+package gohtml
+
+import (
+	"errors"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/saihon/gohtml/attr"
+)
+
+// Select is the <select> element
+type Select struct {
+	Element
+}
+
+// Select returns the element as "*Select" if it is <select>, otherwise nil
+func (e Element) Select() *Select {
+	if e.Node == nil || e.Node.Type != html.ElementNode || e.Node.DataAtom != atom.Select {
+		return nil
+	}
+	return &Select{e}
+}
+
+// NewOption creates <option> like the Option constructor of JavaScript.
+// selected sets the selected attribute, which is the default selectedness
+func NewOption(text, value string, selected bool) *Element {
+	o := CreateElement("option")
+	if text != "" {
+		o.Node.AppendChild(&html.Node{Type: html.TextNode, Data: text})
+	}
+	o.Node.Attr = append(o.Node.Attr, html.Attribute{Key: "value", Val: value})
+	if selected {
+		o.Node.Attr = append(o.Node.Attr, html.Attribute{Key: "selected"})
+	}
+	return o
+}
+
+// Multiple returns true if the select has the multiple attribute
+func (s Select) Multiple() bool {
+	return attr.Has(s.Node, "multiple")
+}
+
+// Size returns the display size, which is the size attribute if valid,
+// otherwise 4 for the multiple select and 1 for the drop-down box
+func (s Select) Size() int {
+	return displaySize(s.Node)
+}
+
+// Options returns the options of the select in the tree order,
+// including the options in <optgroup>
+func (s Select) Options() Collection {
+	return Collection{Nodes: options(s.Node)}
+}
+
+// OptGroups returns the <optgroup> children of the select
+func (s Select) OptGroups() Collection {
+	return Collection{Nodes: childElements(s.Node, atom.Optgroup)}
+}
+
+// Length returns the number of the options
+func (s Select) Length() int {
+	return len(options(s.Node))
+}
+
+// Item returns the option at the index, or nil if out of range
+func (s Select) Item(index int) *Element {
+	list := options(s.Node)
+	if index < 0 || index >= len(list) {
+		return nil
+	}
+	return &Element{list[index]}
+}
+
+// NamedItem returns the first option that has the id or the name, or nil
+func (s Select) NamedItem(name string) *Element {
+	for _, o := range options(s.Node) {
+		if attr.Get(o, "id") == name || attr.Get(o, "name") == name {
+			return &Element{o}
+		}
+	}
+	return nil
+}
+
+// SelectedIndex returns the index of the first selected option, or -1
+func (s Select) SelectedIndex() int {
+	selected := selectedOptions(s.Node)
+	if len(selected) == 0 {
+		return -1
+	}
+	for i, o := range options(s.Node) {
+		if o == selected[0] {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetSelectedIndex selects the option at the index and unselects the
+// others. nothing is selected if the index is out of range. the
+// selectedness is kept until Release of the document like Selected
+func (s Select) SetSelectedIndex(index int) {
+	for i, o := range options(s.Node) {
+		st := ensureState(o)
+		st.selected, st.dirtySelected = i == index, true
+	}
+}
+
+// SetValue selects the first option that has the value and unselects
+// the others. it returns false and nothing is selected if no option has
+// it. the selectedness is kept until Release of the document like Selected
+func (s Select) SetValue(value string) bool {
+	selectValue(s.Node, value)
+	return len(selectedOptions(s.Node)) > 0
+}
+
+// Values returns the values of the selected options
+func (s Select) Values() []string {
+	var values []string
+	for _, o := range selectedOptions(s.Node) {
+		values = append(values, optionValue(o))
+	}
+	return values
+}
+
+// SetValues selects the options that have one of the values and
+// unselects the others. the single select takes the last one selected.
+// the selectedness is kept until Release of the document like Selected
+func (s Select) SetValues(values ...string) {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	for _, o := range options(s.Node) {
+		st := ensureState(o)
+		st.selected, st.dirtySelected = set[optionValue(o)], true
+	}
+}
+
+// Add inserts <option> or <optgroup> before the option or the optgroup
+// of the select like add of JavaScript, or appends it if before is nil.
+// the selected option inserted to a single select unselects the others,
+// which is kept until Release of the document like Selected
+func (s Select) Add(e *Element, before ...*Element) error {
+	n := e.Node
+	if n.Type != html.ElementNode || (n.DataAtom != atom.Option && n.DataAtom != atom.Optgroup) {
+		return errors.New("gohtml: Add needs <option> or <optgroup>")
+	}
+	if contains(n, s.Node) {
+		return errors.New("gohtml: Add cannot insert an ancestor of the select")
+	}
+	parent, next := s.Node, (*html.Node)(nil)
+	if len(before) > 0 && before[0] != nil {
+		next = before[0].Node
+		if next == s.Node || !contains(s.Node, next) {
+			return errors.New("gohtml: the reference of Add is not in the select")
+		}
+		parent = next.Parent
+	}
+	if n == next {
+		return nil
+	}
+
+	if n.Parent != nil {
+		(&Element{n}).Remove()
+	}
+	mutateChildren(parent, func() error {
+		parent.InsertBefore(n, next)
+		return nil
+	})
+
+	if !s.Multiple() {
+		var last *html.Node
+		list := options(s.Node)
+		for _, o := range list {
+			if contains(n, o) && optionSelected(o) {
+				last = o
+			}
+		}
+		if last != nil {
+			for _, o := range list {
+				st := ensureState(o)
+				st.selected, st.dirtySelected = o == last, true
+			}
+			return nil
+		}
+	}
+	s.selectedness()
+	return nil
+}
+
+// contains returns true if n is an inclusive descendant of a
+func contains(a, n *html.Node) bool {
+	for ; n != nil; n = n.Parent {
+		if n == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove removes the option at the index like remove of JavaScript.
+// without the index, it removes the select itself. the selectedness
+// updated by the removal is kept until Release of the document
+func (s Select) Remove(index ...int) {
+	if index == nil {
+		s.Element.Remove()
+		return
+	}
+	if o := s.Item(index[0]); o != nil {
+		o.Remove()
+		s.selectedness()
+	}
+}
+
+// selectedness runs the selectedness setting algorithm after the options
+// are changed. the drop-down box that no option is selected selects its
+// first option that is not disabled. the selectedness given by the
+// attributes is left as it is, since selectedOptions applies the rules
+func (s Select) selectedness() {
+	if s.Multiple() {
+		return
+	}
+	list := options(s.Node)
+	dirty := false
+	for _, o := range list {
+		if st := stateOf(o); st != nil && st.dirtySelected {
+			dirty = true
+		}
+	}
+	if !dirty {
+		return
+	}
+	selected := selectedOptions(s.Node)
+	if len(selected) == 0 && displaySize(s.Node) == 1 {
+		for _, o := range list {
+			if !disabledOption(o) {
+				selected = []*html.Node{o}
+				break
+			}
+		}
+	}
+	for _, o := range list {
+		st := ensureState(o)
+		st.selected, st.dirtySelected = len(selected) > 0 && o == selected[0], true
+	}
+}
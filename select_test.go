@@ -0,0 +1,127 @@
+package gohtml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testSelect(t *testing.T, s string) (*Select, *Document) {
+	doc, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc.QuerySelector("select").Select(), doc
+}
+
+func TestSelect(t *testing.T) {
+	s, doc := testSelect(t, `<select>
+<option disabled>x</option>
+<optgroup label="g"><option id="a">a</option><option value="2">b</option></optgroup>
+<option>c</option>
+</select>`)
+	if s == nil || doc.Body().Select() != nil {
+		t.Fatal("\nSelect is wrong\n")
+	}
+
+	if s.Length() != 4 || s.OptGroups().Length() != 1 || s.Multiple() || s.Size() != 1 {
+		t.Errorf("\nthe options are wrong\n")
+	}
+	if actual := s.SelectedIndex(); actual != 1 {
+		t.Errorf("\nthe first option that is not disabled must be selected, got: %d\n", actual)
+	}
+	if s.Value() != "a" || s.NamedItem("a") == nil || s.Item(2).Value() != "2" || s.Item(4) != nil {
+		t.Errorf("\nthe values are wrong\n")
+	}
+
+	if !s.SetValue("2") || s.SelectedIndex() != 2 || !s.Item(2).Selected() || s.Item(1).Selected() {
+		t.Errorf("\nSetValue is wrong\n")
+	}
+	if s.SetValue("none") || s.SelectedIndex() != -1 || s.Value() != "" {
+		t.Errorf("\nno option must be selected\n")
+	}
+	s.SetSelectedIndex(3)
+	if s.Value() != "c" {
+		t.Errorf("\ngot : %q, want: c\n", s.Value())
+	}
+
+	// removing the selected option selects the first one again
+	s.Remove(3)
+	if s.Length() != 3 || s.SelectedIndex() != 1 {
+		t.Errorf("\ngot : %d, want: 1\n", s.SelectedIndex())
+	}
+	if err := s.Add(NewOption("d", "4", true), s.Item(2)); err != nil {
+		t.Fatal(err)
+	}
+	if s.Item(2).TextContent() != "d" || s.Item(2).Node.Parent.Data != "optgroup" || s.Value() != "4" {
+		t.Errorf("\nAdd is wrong, got: %q\n", s.Value())
+	}
+	if err := s.Add(CreateElement("div")); err == nil {
+		t.Errorf("\n<div> must be an error\n")
+	}
+	if err := s.Add(NewOption("e", "", false), doc.Body()); err == nil {
+		t.Errorf("\nthe reference out of the select must be an error\n")
+	}
+
+	s.Remove()
+	if s.Node.Parent != nil {
+		t.Errorf("\nthe select must be removed\n")
+	}
+}
+
+func TestSelectMultiple(t *testing.T) {
+	s, _ := testSelect(t, `<select multiple><option selected>a</option><option>b</option><option selected>c</option></select>`)
+	if s.Size() != 4 || s.SelectedIndex() != 0 {
+		t.Errorf("\nthe multiple select is wrong\n")
+	}
+	if actual := s.Values(); !reflect.DeepEqual(actual, []string{"a", "c"}) {
+		t.Errorf("\ngot : %q\n", actual)
+	}
+	s.SetValues("b", "c")
+	if actual := s.Values(); !reflect.DeepEqual(actual, []string{"b", "c"}) {
+		t.Errorf("\ngot : %q\n", actual)
+	}
+	s.Remove(1)
+	if actual := s.Values(); !reflect.DeepEqual(actual, []string{"c"}) {
+		t.Errorf("\ngot : %q\n", actual)
+	}
+
+	// the list box selects nothing by default
+	s, _ = testSelect(t, `<select size="2"><option>a</option></select>`)
+	if s.SelectedIndex() != -1 {
+		t.Errorf("\nthe list box must select nothing\n")
+	}
+	// the last selected option wins in the single select
+	s, _ = testSelect(t, `<select><option selected>a</option><option selected>b</option></select>`)
+	if s.Value() != "b" {
+		t.Errorf("\ngot : %q, want: b\n", s.Value())
+	}
+}
+
+func TestSelectAdd(t *testing.T) {
+	s, _ := testSelect(t, `<select><option>a</option><option>b</option></select>`)
+	s.SetValue("b")
+
+	// the selected option inserted unselects the others
+	if err := s.Add(NewOption("c", "c", true), s.Item(0)); err != nil {
+		t.Fatal(err)
+	}
+	if s.Value() != "c" || s.SelectedIndex() != 0 || s.Item(2).Selected() {
+		t.Errorf("\ngot : %q, want: c\n", s.Value())
+	}
+	// the option not selected keeps the selection
+	if err := s.Add(NewOption("d", "d", false)); err != nil {
+		t.Fatal(err)
+	}
+	if s.Value() != "c" {
+		t.Errorf("\ngot : %q, want: c\n", s.Value())
+	}
+
+	// the multiple select keeps the others selected
+	s, _ = testSelect(t, `<select multiple><option>a</option><option>b</option></select>`)
+	s.SetValues("b")
+	s.Add(NewOption("c", "c", true))
+	if actual := s.Values(); !reflect.DeepEqual(actual, []string{"b", "c"}) {
+		t.Errorf("\ngot : %q\n", actual)
+	}
+}
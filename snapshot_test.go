@@ -0,0 +1,95 @@
+package gohtml
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(test_json + `<custom-tag data-x="1">é</custom-tag>`))
+
+	var buf bytes.Buffer
+	if err := doc.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ReadSnapshot(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalTree(doc.Node, again.Node) {
+		t.Errorf("\nround trip is not same\n")
+	}
+
+	data := buf.Bytes()
+	for i := range data {
+		b := append([]byte(nil), data...)
+		b[i] ^= 0x40
+		if _, err := ReadSnapshot(bytes.NewReader(b)); !errors.Is(err, ErrSnapshotCorrupt) {
+			t.Fatalf("\nbyte %d is changed: got : %v, want: %v\n", i, err, ErrSnapshotCorrupt)
+		}
+	}
+	if _, err := ReadSnapshot(bytes.NewReader(data[:len(data)-1])); !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Errorf("\ntruncated: got : %v, want: %v\n", err, ErrSnapshotCorrupt)
+	}
+
+	// the attributes are sliced from one block
+	var s strings.Builder
+	s.WriteString("<p")
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&s, " a%d", i)
+	}
+	s.WriteString(`></p><i id="a"></i><b id="b"></b>`)
+	doc, _ = Parse(strings.NewReader(s.String()))
+	buf.Reset()
+	doc.WriteSnapshot(&buf)
+	if again, err = ReadSnapshot(&buf); err != nil || !equalTree(doc.Node, again.Node) {
+		t.Fatalf("\nround trip is not same: %v\n", err)
+	}
+	again.QuerySelector("i").SetAttribute("class", "x")
+	if actual := again.QuerySelector("b").OuterHTML(); actual != `<b id="b"></b>` {
+		t.Errorf("\ngot : %s\n", actual)
+	}
+
+	// a snapshot of the future version
+	b := append([]byte(nil), data[:len(data)-4]...)
+	b[len(snapshotMagic)] = 2
+	b = binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b))
+	if _, err := ReadSnapshot(bytes.NewReader(b)); !errors.Is(err, ErrSnapshotVersion) {
+		t.Errorf("\nversion: got : %v, want: %v\n", err, ErrSnapshotVersion)
+	}
+}
+
+func benchmarkPage() string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html><html><head><title>bench</title></head><body>")
+	for i := 0; i < 500; i++ {
+		b.WriteString(`<div class="row" id="r"><p>Lorem <b>ipsum</b> dolor &amp; sit <a href="/x?a=1&b=2">amet</a></p><ul><li>one</li><li>two</li></ul></div>`)
+	}
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+func BenchmarkParse(b *testing.B) {
+	s := benchmarkPage()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Parse(strings.NewReader(s))
+	}
+}
+
+func BenchmarkReadSnapshot(b *testing.B) {
+	doc, _ := Parse(strings.NewReader(benchmarkPage()))
+	var buf bytes.Buffer
+	doc.WriteSnapshot(&buf)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ReadSnapshot(bytes.NewReader(buf.Bytes()))
+	}
+}
@@ -0,0 +1,667 @@
+package gohtml
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// SourcePosition is a location in the parsed source.
+// Offset is a 0-based byte offset, Line and Column are 1-based
+// and Column counts characters (runes) from the start of the line
+type SourcePosition struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// SourceRange is the part of the source that a node or an attribute
+// was parsed from. End is exclusive
+type SourceRange struct {
+	Start SourcePosition
+	End   SourcePosition
+}
+
+// markerKey is the attribute injected to every start tag before parsing,
+// for finds out which element has been created from which tag
+const markerKey = "data-gohtml-srcpos"
+
+// ParseWithPositions parses HTML like Parse, and records the source
+// position of every node and attribute. these are returned from
+// Element.SourceRange and Element.AttributeSourceRange.
+// the recorded positions are kept until Document.Release is called
+func ParseWithPositions(r io.Reader) (*Document, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	marked, toks := scanSource(src)
+	n, err := html.Parse(bytes.NewReader(marked))
+	if err != nil {
+		return nil, err
+	}
+
+	m := newSourceMap(src)
+	m.nodes[n] = &sourceNode{end: len(src), endTag: len(src)}
+	m.bind(n, toks)
+	m.measure(n)
+	ensureDocData(n).source = m
+	return &Document{n}, nil
+}
+
+// sourceToken is a token read from the source
+type sourceToken struct {
+	kind   html.TokenType
+	name   string
+	data   string
+	plain  bool // the raw text is the same as data
+	start  int
+	end    int
+	tail   int // the end of the tag name or the last attribute
+	attrs  []sourceAttr
+	endTag int // index of the closing end tag, -1 if omitted
+	seen   bool
+	used   int // bytes of data already matched to text nodes
+}
+
+// sourceAttr is an attribute read from the raw start tag
+type sourceAttr struct {
+	Namespace string
+	Key       string
+	Val       string
+	start     int
+	end       int
+}
+
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "keygen": true, "link": true,
+	"meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// formattingElements are closed by the adoption agency algorithm,
+// which leaves the special elements opened in them open
+var formattingElements = map[string]bool{
+	"a": true, "b": true, "big": true, "code": true, "em": true,
+	"font": true, "i": true, "nobr": true, "s": true, "small": true,
+	"strike": true, "strong": true, "tt": true, "u": true,
+}
+
+var specialElements = map[string]bool{
+	"address": true, "applet": true, "article": true, "aside": true,
+	"blockquote": true, "body": true, "button": true, "caption": true,
+	"center": true, "colgroup": true, "dd": true, "details": true,
+	"dir": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true,
+	"frameset": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "head": true, "header": true, "hgroup": true,
+	"html": true, "iframe": true, "li": true, "listing": true, "main": true,
+	"marquee": true, "menu": true, "nav": true, "noembed": true,
+	"noframes": true, "noscript": true, "object": true, "ol": true,
+	"p": true, "plaintext": true, "pre": true, "script": true,
+	"search": true, "section": true, "select": true, "style": true,
+	"summary": true, "table": true, "tbody": true, "td": true,
+	"template": true, "textarea": true, "tfoot": true, "th": true,
+	"thead": true, "title": true, "tr": true, "ul": true, "xmp": true,
+}
+
+// closeOpen closes the open element matched with the end tag
+// of name at index i as the parser does, and returns the elements
+// still open
+func closeOpen(toks []*sourceToken, open []int, name string, i int) []int {
+	for j := len(open) - 1; j >= 0; j-- {
+		if toks[open[j]].name != name {
+			continue
+		}
+		toks[open[j]].endTag = i
+		rest := open[j+1:]
+		open = open[:j]
+		if formattingElements[name] {
+			// the special elements are moved out of the formatting
+			// element and stay open, the others are closed
+			for _, k := range rest {
+				if specialElements[toks[k].name] {
+					open = append(open, k)
+				}
+			}
+		}
+		return open
+	}
+	return open
+}
+
+// scanSource tokenizes src and returns the copy of src that
+// the marker attribute was injected to each start tag
+func scanSource(src []byte) ([]byte, []*sourceToken) {
+	var (
+		buf     bytes.Buffer
+		toks    []*sourceToken
+		open    []int
+		foreign int
+		offset  int
+	)
+
+	z := html.NewTokenizer(bytes.NewReader(src))
+	for {
+		tt := z.Next()
+		// Text unescapes the data in place, so copy before it
+		raw := append([]byte(nil), z.Raw()...)
+		t := &sourceToken{kind: tt, start: offset, end: offset + len(raw), endTag: -1}
+		offset = t.end
+
+		switch tt {
+		case html.ErrorToken:
+			buf.Write(raw)
+			return buf.Bytes(), toks
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			t.name = string(name)
+			t.attrs = scanAttrs(raw, t.start)
+
+			i := tagNameEnd(raw)
+			t.tail = t.start + i
+			if len(t.attrs) > 0 {
+				t.tail = t.attrs[len(t.attrs)-1].end
+			}
+			buf.Write(raw[:i])
+			buf.WriteString(" " + markerKey + `="` + strconv.Itoa(len(toks)) + `"`)
+			buf.Write(raw[i:])
+
+			if !voidElements[t.name] {
+				open = append(open, len(toks))
+			}
+			if tt == html.StartTagToken && (t.name == "svg" || t.name == "math") {
+				foreign++
+			}
+			if foreign > 0 {
+				z.NextIsNotRawText()
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			t.name = string(name)
+			open = closeOpen(toks, open, t.name, len(toks))
+			if foreign > 0 && (t.name == "svg" || t.name == "math") {
+				foreign--
+			}
+			buf.Write(raw)
+		default:
+			t.data = string(z.Text())
+			t.plain = t.data == string(raw)
+			buf.Write(raw)
+		}
+		z.AllowCDATA(foreign > 0)
+		toks = append(toks, t)
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// tagNameEnd returns the index just after the tag name in a raw start tag
+func tagNameEnd(raw []byte) int {
+	i := 1
+	for i < len(raw) && !isSpace(raw[i]) && raw[i] != '/' && raw[i] != '>' {
+		i++
+	}
+	return i
+}
+
+// scanAttrs reads attributes from a raw start tag the same way
+// as html.Tokenizer. base is the offset of the tag in the source
+func scanAttrs(raw []byte, base int) []sourceAttr {
+	var attrs []sourceAttr
+
+	end := len(raw)
+	if end > 0 && raw[end-1] == '>' {
+		end--
+	}
+	i := tagNameEnd(raw)
+	for {
+		for i < end && (isSpace(raw[i]) || raw[i] == '/') {
+			i++
+		}
+		if i >= end {
+			return attrs
+		}
+
+		kstart := i
+		i++
+		for i < end && !isSpace(raw[i]) && raw[i] != '/' && raw[i] != '=' {
+			i++
+		}
+		a := sourceAttr{
+			Key:   strings.ToLower(string(raw[kstart:i])),
+			start: base + kstart,
+			end:   base + i,
+		}
+
+		j := i
+		for j < end && isSpace(raw[j]) {
+			j++
+		}
+		if j < end && raw[j] == '=' {
+			j++
+			for j < end && isSpace(raw[j]) {
+				j++
+			}
+			vstart, vend := j, j
+			if j < end && (raw[j] == '"' || raw[j] == '\'') {
+				q := raw[j]
+				vstart = j + 1
+				vend = vstart
+				for vend < end && raw[vend] != q {
+					vend++
+				}
+				i = vend
+				if i < end {
+					i++
+				}
+			} else {
+				for vend < end && !isSpace(raw[vend]) {
+					vend++
+				}
+				i = vend
+			}
+			a.Val = html.UnescapeString(string(raw[vstart:vend]))
+			a.end = base + i
+		}
+		attrs = append(attrs, a)
+	}
+}
+
+// sourceNode is the recorded position of a node
+type sourceNode struct {
+	start   int
+	end     int
+	tagEnd  int // the end of the start tag
+	tagTail int // the end of the tag name or the last attribute
+	endTag  int // the start of the end tag, -1 if omitted
+	// implied is true if the parser created the node
+	// without the corresponding token in the source
+	implied bool
+	// exact is true if the source text of the node
+	// makes the same subtree when it is parsed again
+	exact         bool
+	attrs         []sourceAttr
+	modified      bool
+	attrsModified bool
+}
+
+// sourceMap holds the source and the positions of the parsed nodes
+type sourceMap struct {
+	mu    sync.Mutex
+	src   []byte
+	lines []int
+	nodes map[*html.Node]*sourceNode
+	// starts is the sorted start offsets of all parsed nodes
+	starts []int
+}
+
+func newSourceMap(src []byte) *sourceMap {
+	m := &sourceMap{
+		src:   src,
+		lines: []int{0},
+		nodes: make(map[*html.Node]*sourceNode),
+	}
+	for i, c := range src {
+		if c == '\n' {
+			m.lines = append(m.lines, i+1)
+		}
+	}
+	return m
+}
+
+// position converts a byte offset to SourcePosition
+func (m *sourceMap) position(offset int) SourcePosition {
+	i := sort.Search(len(m.lines), func(i int) bool {
+		return m.lines[i] > offset
+	})
+	begin := m.lines[i-1]
+	return SourcePosition{
+		Offset: offset,
+		Line:   i,
+		Column: utf8.RuneCount(m.src[begin:offset]) + 1,
+	}
+}
+
+func (m *sourceMap) sourceRange(start, end int) SourceRange {
+	return SourceRange{Start: m.position(start), End: m.position(end)}
+}
+
+// bind removes the marker attribute from the parsed tree and
+// records the position of each node
+func (m *sourceMap) bind(n *html.Node, toks []*sourceToken) {
+	switch n.Type {
+	case html.ElementNode:
+		m.bindElement(n, toks)
+	case html.TextNode:
+		m.bindText(n, toks)
+	case html.CommentNode, html.DoctypeNode:
+		m.bindOther(n, toks)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		m.bind(c, toks)
+	}
+
+	// the end of an element that has no end tag in the source
+	// is the end of the last descendant
+	if s := m.nodes[n]; s != nil && !s.implied && n.Type == html.ElementNode && s.endTag < 0 {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if cs := m.nodes[c]; cs != nil && !cs.implied && cs.end > s.end {
+				s.end = cs.end
+			}
+		}
+	}
+}
+
+func (m *sourceMap) bindElement(n *html.Node, toks []*sourceToken) {
+	index := -1
+	for i, a := range n.Attr {
+		if a.Namespace == "" && a.Key == markerKey {
+			index, _ = strconv.Atoi(a.Val)
+			n.Attr = append(n.Attr[:i], n.Attr[i+1:]...)
+			break
+		}
+	}
+
+	// an element that has no marker was created by the parser, and
+	// an element that has a marker already seen is a clone made by
+	// the adoption agency algorithm
+	if index < 0 || index >= len(toks) || toks[index].seen {
+		m.nodes[n] = &sourceNode{implied: true, endTag: -1}
+		return
+	}
+
+	t := toks[index]
+	t.seen = true
+	s := &sourceNode{start: t.start, end: t.end, tagEnd: t.end, tagTail: t.tail, endTag: -1}
+	if t.endTag >= 0 {
+		s.endTag = toks[t.endTag].start
+		s.end = toks[t.endTag].end
+	}
+
+	// pair the attributes of the node with the raw attributes.
+	// the parser may adjust a name in foreign content, e.g. "xlink:href"
+	j := 0
+	for _, a := range n.Attr {
+		for k := j; k < len(t.attrs); k++ {
+			ra := t.attrs[k]
+			if ra.Key == a.Key || (a.Namespace != "" && ra.Key == a.Namespace+":"+a.Key) {
+				ra.Namespace, ra.Key = a.Namespace, a.Key
+				s.attrs = append(s.attrs, ra)
+				j = k + 1
+				break
+			}
+		}
+	}
+	m.nodes[n] = s
+}
+
+// bound returns the offset that the source of n can not start before
+func (m *sourceMap) bound(n *html.Node) int {
+	for p := n.PrevSibling; p != nil; p = p.PrevSibling {
+		if s := m.nodes[p]; s != nil && !s.implied {
+			return s.end
+		}
+	}
+	if n.Parent == nil {
+		return 0
+	}
+	if s := m.nodes[n.Parent]; s != nil && !s.implied {
+		return s.tagEnd
+	}
+	return m.bound(n.Parent)
+}
+
+func (m *sourceMap) bindText(n *html.Node, toks []*sourceToken) {
+	if n.Data == "" {
+		return
+	}
+	bound := m.bound(n)
+
+	tried := 0
+	first := sort.Search(len(toks), func(i int) bool {
+		return toks[i].end > bound
+	})
+	for i := first; i < len(toks) && tried < 4; i++ {
+		t := toks[i]
+		if t.kind != html.TextToken || t.end <= bound || t.used >= len(t.data) {
+			continue
+		}
+		tried++
+		if t.used == 0 && m.dropsNewline(n, t) {
+			if t.used = 1; t.used == len(t.data) {
+				continue
+			}
+		}
+		rest := t.data[t.used:]
+
+		// the text node consists of the rest of a token and whole tokens
+		if strings.HasPrefix(n.Data, rest) {
+			size, last := len(rest), i
+			for k := i + 1; size < len(n.Data) && k < len(toks); k++ {
+				if toks[k].kind != html.TextToken {
+					continue
+				}
+				if toks[k].used != 0 || !strings.HasPrefix(n.Data[size:], toks[k].data) {
+					break
+				}
+				size += len(toks[k].data)
+				last = k
+			}
+			if size == len(n.Data) {
+				start, ok := m.rawOffset(t, t.used)
+				if !ok {
+					return
+				}
+				for k := i; k <= last; k++ {
+					if toks[k].kind == html.TextToken {
+						toks[k].used = len(toks[k].data)
+					}
+				}
+				m.nodes[n] = &sourceNode{start: start, end: toks[last].end, endTag: -1}
+				return
+			}
+		}
+
+		// the parser split a token into several text nodes
+		if k := strings.Index(rest, n.Data); k >= 0 {
+			start, ok := m.rawOffset(t, t.used+k)
+			end, ok2 := m.rawOffset(t, t.used+k+len(n.Data))
+			if !ok || !ok2 {
+				return
+			}
+			t.used += k + len(n.Data)
+			m.nodes[n] = &sourceNode{start: start, end: end, endTag: -1}
+			return
+		}
+	}
+}
+
+// dropsNewline returns true if the parser ignored the newline
+// at the start of t just after the start tag of the parent of n
+func (m *sourceMap) dropsNewline(n *html.Node, t *sourceToken) bool {
+	p := n.Parent
+	if p == nil || p.Type != html.ElementNode || p.Namespace != "" || !newlineElements[p.Data] {
+		return false
+	}
+	s := m.nodes[p]
+	return s != nil && !s.implied && s.tagEnd == t.start && t.data[0] == '\n'
+}
+
+// rawOffset returns the offset in the source where the first k bytes
+// of the data of the text token t end. ok is false if it is in the
+// middle of a character reference
+func (m *sourceMap) rawOffset(t *sourceToken, k int) (int, bool) {
+	if t.plain {
+		return t.start + k, true
+	}
+	raw := m.src[t.start:t.end]
+	unescape := t.data != strings.ReplaceAll(string(raw), "\r\n", "\n")
+
+	size, last, inRef := 0, 0, false
+	for i := 0; i <= len(raw); i++ {
+		// a reference does not contain '&' after the first character
+		cut := i == len(raw) || !inRef || raw[i] == '&' || !isRefChar(raw[i])
+		if cut && i > 0 && i < len(raw) && raw[i-1] == '\r' && raw[i] == '\n' {
+			cut = false
+		}
+		if cut {
+			seg := strings.ReplaceAll(string(raw[last:i]), "\r\n", "\n")
+			if unescape {
+				seg = html.UnescapeString(seg)
+			}
+			size, last = size+len(seg), i
+			if size == k {
+				return t.start + i, true
+			}
+			if size > k {
+				return 0, false
+			}
+		}
+		if i < len(raw) {
+			if raw[i] == '&' {
+				inRef = true
+			} else if raw[i] == ';' || !isRefChar(raw[i]) {
+				inRef = false
+			}
+		}
+	}
+	return 0, false
+}
+
+func isRefChar(c byte) bool {
+	return c == '#' || c == ';' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func (m *sourceMap) bindOther(n *html.Node, toks []*sourceToken) {
+	kind := html.CommentToken
+	if n.Type == html.DoctypeNode {
+		kind = html.DoctypeToken
+	}
+	bound := m.bound(n)
+	first := sort.Search(len(toks), func(i int) bool {
+		return toks[i].start >= bound
+	})
+	for _, t := range toks[first:] {
+		if t.kind != kind || t.seen {
+			continue
+		}
+		if kind == html.CommentToken && t.data != n.Data {
+			continue
+		}
+		t.seen = true
+		m.nodes[n] = &sourceNode{start: t.start, end: t.end, endTag: -1}
+		return
+	}
+}
+
+// sourceOf returns the source map of the document n belongs to
+func sourceOf(n *html.Node) *sourceMap {
+	if d := lookupDocData(n); d != nil {
+		return d.source
+	}
+	return nil
+}
+
+// touch marks n as modified since it was parsed
+func touch(n *html.Node) {
+	if n == nil {
+		return
+	}
+	m := sourceOf(n)
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s := m.nodes[n]; s != nil {
+		s.modified = true
+	}
+}
+
+// touchAttr drops the recorded position of the attribute of n
+func touchAttr(n *html.Node, namespace, key string) {
+	m := sourceOf(n)
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.nodes[n]
+	if s == nil {
+		return
+	}
+	s.attrsModified = true
+	for i, a := range s.attrs {
+		if a.Key == key && (namespace == "*" || a.Namespace == namespace) {
+			s.attrs = append(s.attrs[:i], s.attrs[i+1:]...)
+			return
+		}
+	}
+}
+
+// SourceRange returns the range of the source that the element was
+// parsed from, including its end tag. ok is false if the document was
+// not parsed by ParseWithPositions or the element was created afterward
+// or implied by the parser. a text, comment or doctype node as the
+// "*Element" also has a range
+func (e Element) SourceRange() (SourceRange, bool) {
+	m := sourceOf(e.Node)
+	if m == nil {
+		return SourceRange{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.nodes[e.Node]
+	if s == nil || s.implied {
+		return SourceRange{}, false
+	}
+	return m.sourceRange(s.start, s.end), true
+}
+
+// AttributeSourceRange returns the range of the source of the attribute
+// with specified key, from the start of the key to the end of the value.
+// the range is cleared when the attribute is changed
+func (e Element) AttributeSourceRange(key string) (SourceRange, bool) {
+	m := sourceOf(e.Node)
+	if m == nil {
+		return SourceRange{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.nodes[e.Node]
+	if s == nil {
+		return SourceRange{}, false
+	}
+	for _, a := range s.attrs {
+		if a.Key == key {
+			return m.sourceRange(a.start, a.end), true
+		}
+	}
+	return SourceRange{}, false
+}
+
+// SourceModified returns true if the children, the text or the
+// attributes of the element have been changed since parsed
+func (e Element) SourceModified() bool {
+	m := sourceOf(e.Node)
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s := m.nodes[e.Node]; s != nil {
+		return s.modified || s.attrsModified
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package gohtml
+
+import (
+	"strings"
+	"testing"
+)
+
+const test_source = `<!DOCTYPE html>
+<html>
+<head><title>t</title></head>
+<body>
+	<div id="main" class='a b'>hello <b>wörld</b></div>
+	<p>one<p>two
+	<!-- note -->
+</body>
+</html>
+`
+
+func sourceText(t *testing.T, e *Element) string {
+	r, ok := e.SourceRange()
+	if !ok {
+		t.Fatalf("\nno source range: %s\n", e.Node.Data)
+	}
+	return test_source[r.Start.Offset:r.End.Offset]
+}
+
+func TestSourceRange(t *testing.T) {
+	doc, err := ParseWithPositions(strings.NewReader(test_source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Release()
+
+	div := doc.GetElementById("main")
+	expect := `<div id="main" class='a b'>hello <b>wörld</b></div>`
+	if actual := sourceText(t, div); actual != expect {
+		t.Errorf("\ngot : %s, want: %s\n", actual, expect)
+	}
+
+	r, _ := div.SourceRange()
+	if r.Start.Line != 5 || r.Start.Column != 2 {
+		t.Errorf("\ngot : %d:%d, want: 5:2\n", r.Start.Line, r.Start.Column)
+	}
+	if r.End.Line != 5 || r.End.Column != 53 {
+		t.Errorf("\ngot : %d:%d, want: 5:53\n", r.End.Line, r.End.Column)
+	}
+
+	// the marker attribute must not be left
+	if len(div.Attributes()) != 2 {
+		t.Errorf("\nunexpected attributes: %v\n", div.Attributes())
+	}
+
+	a, ok := div.AttributeSourceRange("class")
+	if !ok {
+		t.Fatal("\nno source range of the attribute\n")
+	}
+	if actual := test_source[a.Start.Offset:a.End.Offset]; actual != `class='a b'` {
+		t.Errorf("\ngot : %s, want: %s\n", actual, `class='a b'`)
+	}
+
+	// text node
+	text := &Element{div.FirstChild()}
+	if actual := sourceText(t, text); actual != "hello " {
+		t.Errorf("\ngot : %q, want: %q\n", actual, "hello ")
+	}
+
+	// an element without end tag ends at the last descendant
+	p := doc.QuerySelector("p")
+	if actual := sourceText(t, p); actual != "<p>one" {
+		t.Errorf("\ngot : %q, want: %q\n", actual, "<p>one")
+	}
+
+	// comment
+	c := doc.QuerySelectorAll("p").Get(1).LastChild().PrevSibling
+	if actual := sourceText(t, &Element{c}); actual != "<!-- note -->" {
+		t.Errorf("\ngot : %q, want: %q\n", actual, "<!-- note -->")
+	}
+}
+
+func TestSourceRangeImplied(t *testing.T) {
+	doc, err := ParseWithPositions(strings.NewReader(`<table><tr><td>x</td></tr></table>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Release()
+
+	for _, tag := range []string{"html", "head", "body", "tbody"} {
+		e := doc.QuerySelector(tag)
+		if _, ok := e.SourceRange(); ok {
+			t.Errorf("\n<%s> is implied, should not have a range\n", tag)
+		}
+	}
+	if _, ok := doc.QuerySelector("td").SourceRange(); !ok {
+		t.Errorf("\n<td> should have a range\n")
+	}
+}
+
+func TestSourceRangeClone(t *testing.T) {
+	s := `<p><b>x<p>y</b>`
+	doc, err := ParseWithPositions(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Release()
+
+	b := doc.QuerySelectorAll("b")
+	if b.Length() != 2 {
+		t.Fatalf("\ngot : %d, want: 2\n", b.Length())
+	}
+	if _, ok := b.Get(0).SourceRange(); !ok {
+		t.Errorf("\nthe first <b> should have a range\n")
+	}
+	if _, ok := b.Get(1).SourceRange(); ok {
+		t.Errorf("\nthe clone of <b> should not have a range\n")
+	}
+}
+
+func TestSourceRangeMisnested(t *testing.T) {
+	tests := []struct {
+		input, selector, expect string
+	}{
+		{`<b><p>one</b>two</p>`, "p", `<p>one</b>two</p>`},
+		{`<b><p>one</b>two</p>`, "b", `<b><p>one</b>`},
+		{`<b><i>x</b>y`, "i", `<i>x`},
+		{`<a><div><span>x</a>y</span></div>`, "div", `<div><span>x</a>y</span></div>`},
+		{`<a><div><span>x</a>y</span></div>`, "span", `<span>x`},
+	}
+	for _, tt := range tests {
+		doc, err := ParseWithPositions(strings.NewReader(tt.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, ok := doc.QuerySelector(tt.selector).SourceRange()
+		if !ok {
+			t.Fatalf("\n%s: no source range of <%s>\n", tt.input, tt.selector)
+		}
+		if actual := tt.input[r.Start.Offset:r.End.Offset]; actual != tt.expect {
+			t.Errorf("\n%s: got : %q, want: %q\n", tt.input, actual, tt.expect)
+		}
+		doc.Release()
+	}
+}
+
+func TestSourceRangeEntity(t *testing.T) {
+	s := `<p>a &lt;b&gt; c</p>`
+	doc, err := ParseWithPositions(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Release()
+
+	p := doc.QuerySelector("p")
+	if actual := p.TextContent(); actual != "a <b> c" {
+		t.Errorf("\ngot : %q, want: %q\n", actual, "a <b> c")
+	}
+	if p.QuerySelector("b") != nil {
+		t.Errorf("\nthe character references must not be parsed as a tag\n")
+	}
+	r, ok := (&Element{p.FirstChild()}).SourceRange()
+	if !ok {
+		t.Fatal("\nno source range of the text\n")
+	}
+	if actual := s[r.Start.Offset:r.End.Offset]; actual != "a &lt;b&gt; c" {
+		t.Errorf("\ngot : %q, want: %q\n", actual, "a &lt;b&gt; c")
+	}
+}
+
+func TestSourceModified(t *testing.T) {
+	doc, err := ParseWithPositions(strings.NewReader(test_source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Release()
+
+	div := doc.GetElementById("main")
+	if div.SourceModified() {
+		t.Errorf("\nshould not be modified yet\n")
+	}
+
+	div.SetAttribute("class", "c")
+	if !div.SourceModified() {
+		t.Errorf("\nshould be modified\n")
+	}
+	if _, ok := div.AttributeSourceRange("class"); ok {
+		t.Errorf("\nthe range of changed attribute should be cleared\n")
+	}
+	if _, ok := div.AttributeSourceRange("id"); !ok {
+		t.Errorf("\nthe range of unchanged attribute should be kept\n")
+	}
+
+	body := doc.Body()
+	body.AppendChild(CreateElement("span"))
+	if !body.SourceModified() {
+		t.Errorf("\nshould be modified\n")
+	}
+
+	doc.Release()
+	if _, ok := div.SourceRange(); ok {
+		t.Errorf("\nshould not have a range after released\n")
+	}
+}
+
+func TestSourceRangeWithoutPositions(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(test_source))
+	if _, ok := doc.GetElementById("main").SourceRange(); ok {
+		t.Errorf("\nshould not have a range\n")
+	}
+}
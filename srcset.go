@@ -0,0 +1,152 @@
+package gohtml
+
+import (
+	"strconv"
+	"strings"
+)
+
+// srcsetItem is a candidate of srcset split by the parsing algorithm of
+// HTML, the URL and the descriptors as they are written
+type srcsetItem struct {
+	url         string
+	descriptors []string
+}
+
+func isSrcsetSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// splitSrcset splits srcset into the candidates. the descriptors are not
+// validated, a comma in the parentheses does not end the candidate
+func splitSrcset(s string) []srcsetItem {
+	var items []srcsetItem
+	i := 0
+	for {
+		for i < len(s) && (isSrcsetSpace(s[i]) || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return items
+		}
+		start := i
+		for i < len(s) && !isSrcsetSpace(s[i]) {
+			i++
+		}
+		item := srcsetItem{url: s[start:i]}
+		if strings.HasSuffix(item.url, ",") {
+			// the trailing commas end the candidate without descriptors
+			item.url = strings.TrimRight(item.url, ",")
+			if item.url != "" {
+				items = append(items, item)
+			}
+			continue
+		}
+
+		var token strings.Builder
+		parens := false
+		flush := func() {
+			if token.Len() > 0 {
+				item.descriptors = append(item.descriptors, token.String())
+				token.Reset()
+			}
+		}
+	descriptors:
+		for ; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case parens:
+				token.WriteByte(c)
+				parens = c != ')'
+			case c == ',':
+				i++
+				break descriptors
+			case isSrcsetSpace(c):
+				flush()
+			case c == '(':
+				token.WriteByte(c)
+				parens = true
+			default:
+				token.WriteByte(c)
+			}
+		}
+		flush()
+		items = append(items, item)
+	}
+}
+
+// formatSrcset serializes the candidates as srcset
+func formatSrcset(items []srcsetItem) string {
+	list := make([]string, len(items))
+	for i, item := range items {
+		list[i] = strings.Join(append([]string{item.url}, item.descriptors...), " ")
+	}
+	return strings.Join(list, ", ")
+}
+
+// ImageCandidate is an image candidate of srcset. Width, Height and
+// Density are 0 if the descriptor is not given
+type ImageCandidate struct {
+	URL     string
+	Width   int
+	Height  int
+	Density float64
+}
+
+// ParseSrcset parses srcset by the parsing algorithm of HTML.
+// the candidates that have invalid descriptors are dropped
+func ParseSrcset(s string) []ImageCandidate {
+	var list []ImageCandidate
+	for _, item := range splitSrcset(s) {
+		if c, ok := parseCandidate(item); ok {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// parseCandidate parses the descriptors of the candidate
+func parseCandidate(item srcsetItem) (ImageCandidate, bool) {
+	c := ImageCandidate{URL: item.url}
+	hasDensity := false
+	for _, d := range item.descriptors {
+		value := d[:len(d)-1]
+		switch d[len(d)-1] {
+		case 'w':
+			n, ok := parseDimension(value)
+			if !ok || c.Width != 0 || hasDensity {
+				return c, false
+			}
+			c.Width = n
+		case 'x':
+			f, ok := parseNumber(value)
+			if !ok || f < 0 || c.Width != 0 || c.Height != 0 || hasDensity {
+				return c, false
+			}
+			c.Density, hasDensity = f, true
+		case 'h':
+			n, ok := parseDimension(value)
+			if !ok || c.Height != 0 || hasDensity {
+				return c, false
+			}
+			c.Height = n
+		default:
+			return c, false
+		}
+	}
+	// the height is only for the future with the width
+	return c, c.Height == 0 || c.Width != 0
+}
+
+// parseDimension parses the valid non-negative integer greater than 0
+func parseDimension(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n > 0
+}
@@ -0,0 +1,26 @@
+package gohtml
+
+import (
+	"testing"
+)
+
+func TestSplitSrcset(t *testing.T) {
+	tests := []struct {
+		input, expect string
+	}{
+		{"a.png", "a.png"},
+		{"a.png 1x, b.png 2x", "a.png 1x, b.png 2x"},
+		{"  a.png  100w ,b.png\t200w", "a.png 100w, b.png 200w"},
+		{"a.png,b.png 2x", "a.png,b.png 2x"},
+		{"a.png, b.png,, c.png", "a.png, b.png, c.png"},
+		{"a,b.png 1x,c.png", "a,b.png 1x, c.png"},
+		{"data:image/png;base64,AAA= 1x", "data:image/png;base64,AAA= 1x"},
+		{"a.png f(1, 2) 1x, b.png", "a.png f(1, 2) 1x, b.png"},
+		{",, ,", ""},
+	}
+	for _, tt := range tests {
+		if actual := formatSrcset(splitSrcset(tt.input)); actual != tt.expect {
+			t.Errorf("\n%q: got : %q, want: %q\n", tt.input, actual, tt.expect)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package gohtml
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/saihon/gohtml/attr"
+)
+
+// Table is the <table> element
+type Table struct {
+	Element
+}
+
+// Table returns the element as "*Table" if it is <table>, otherwise nil
+func (e Element) Table() *Table {
+	if e.Node == nil || e.Node.Type != html.ElementNode || e.Node.DataAtom != atom.Table {
+		return nil
+	}
+	return &Table{e}
+}
+
+// Cell is a cell in the grid of the table. the slots that the cell spans
+// share the same "*Cell", whose Row and Col are the top left slot
+type Cell struct {
+	*Element
+	Row     int
+	Col     int
+	RowSpan int
+	ColSpan int
+}
+
+// Header returns true if the cell is <th>
+func (c *Cell) Header() bool {
+	return c.Node.DataAtom == atom.Th
+}
+
+// childElement returns the first child element of n with the atom
+func childElement(n *html.Node, a atom.Atom) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == a {
+			return c
+		}
+	}
+	return nil
+}
+
+// childElements returns the child elements of n with the atoms
+func childElements(n *html.Node, atoms ...atom.Atom) []*html.Node {
+	var list []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		for _, a := range atoms {
+			if c.DataAtom == a {
+				list = append(list, c)
+				break
+			}
+		}
+	}
+	return list
+}
+
+func elementOrNil(n *html.Node) *Element {
+	if n == nil {
+		return nil
+	}
+	return &Element{n}
+}
+
+// Caption returns the first <caption>, or nil
+func (t Table) Caption() *Element {
+	return elementOrNil(childElement(t.Node, atom.Caption))
+}
+
+// Head returns the first <thead>, or nil
+func (t Table) Head() *Element {
+	return elementOrNil(childElement(t.Node, atom.Thead))
+}
+
+// Foot returns the first <tfoot>, or nil
+func (t Table) Foot() *Element {
+	return elementOrNil(childElement(t.Node, atom.Tfoot))
+}
+
+// Bodies returns all of <tbody>
+func (t Table) Bodies() Collection {
+	return Collection{Nodes: childElements(t.Node, atom.Tbody)}
+}
+
+// Rows returns the rows of the table like table.rows of JavaScript,
+// the rows of <thead> first, then the rows of the table itself and
+// <tbody> in the tree order, then the rows of <tfoot>.
+// the rows of the nested tables are not included
+func (t Table) Rows() Collection {
+	var head, body, foot []*html.Node
+	for c := t.Node.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.DataAtom {
+		case atom.Thead:
+			head = append(head, childElements(c, atom.Tr)...)
+		case atom.Tbody:
+			body = append(body, childElements(c, atom.Tr)...)
+		case atom.Tfoot:
+			foot = append(foot, childElements(c, atom.Tr)...)
+		case atom.Tr:
+			body = append(body, c)
+		}
+	}
+	return Collection{Nodes: append(append(head, body...), foot...)}
+}
+
+// Cells returns all of <td> and <th> in the order of Rows
+func (t Table) Cells() Collection {
+	var c Collection
+	for _, tr := range t.Rows().Nodes {
+		c.Nodes = append(c.Nodes, childElements(tr, atom.Td, atom.Th)...)
+	}
+	return c
+}
+
+// spanValue parses colspan or rowspan, def is returned if invalid
+func spanValue(n *html.Node, key string, def, max int) int {
+	v, err := strconv.Atoi(strings.TrimSpace(attr.Get(n, key)))
+	if err != nil || v < 0 {
+		return def
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// tableModel forms the grid of the table by the table model of HTML
+type tableModel struct {
+	grid    [][]*Cell
+	width   int
+	height  int
+	y       int
+	growing []*Cell
+}
+
+// slot returns the cell at x, y, extending the grid if needed
+func (m *tableModel) slot(x, y int) **Cell {
+	for len(m.grid) <= y {
+		m.grid = append(m.grid, nil)
+	}
+	for len(m.grid[y]) <= x {
+		m.grid[y] = append(m.grid[y], nil)
+	}
+	return &m.grid[y][x]
+}
+
+func (m *tableModel) covered(x, y int) bool {
+	return y < len(m.grid) && x < len(m.grid[y]) && m.grid[y][x] != nil
+}
+
+// grow extends the cells of rowspan=0 to the current row
+func (m *tableModel) grow() {
+	for _, c := range m.growing {
+		for y := c.Row + c.RowSpan; y <= m.y; y++ {
+			for x := c.Col; x < c.Col+c.ColSpan; x++ {
+				if s := m.slot(x, y); *s == nil {
+					*s = c
+				}
+			}
+		}
+		if m.y+1 > c.Row+c.RowSpan {
+			c.RowSpan = m.y + 1 - c.Row
+		}
+	}
+}
+
+func (m *tableModel) row(tr *html.Node) {
+	if m.height == m.y {
+		m.height++
+	}
+	x := 0
+	m.grow()
+	for _, td := range childElements(tr, atom.Td, atom.Th) {
+		for x < m.width && m.covered(x, m.y) {
+			x++
+		}
+		if x == m.width {
+			m.width++
+		}
+		colspan := spanValue(td, "colspan", 1, 1000)
+		if colspan == 0 {
+			colspan = 1
+		}
+		rowspan := spanValue(td, "rowspan", 1, 65534)
+		grows := rowspan == 0
+		if grows {
+			rowspan = 1
+		}
+		if m.width < x+colspan {
+			m.width = x + colspan
+		}
+		if m.height < m.y+rowspan {
+			m.height = m.y + rowspan
+		}
+
+		c := &Cell{Element: &Element{td}, Row: m.y, Col: x, RowSpan: rowspan, ColSpan: colspan}
+		for dy := 0; dy < rowspan; dy++ {
+			for dx := 0; dx < colspan; dx++ {
+				// the overlapped slots are kept by the first cell
+				if s := m.slot(x+dx, m.y+dy); *s == nil {
+					*s = c
+				}
+			}
+		}
+		if grows {
+			m.growing = append(m.growing, c)
+		}
+		x += colspan
+	}
+	m.y++
+}
+
+// endGroup ends the row group. the rows that the cells span beyond
+// the last row are kept, and rowspan=0 reaches the end of the group
+func (m *tableModel) endGroup() {
+	for m.y < m.height {
+		m.grow()
+		m.y++
+	}
+	m.growing = nil
+}
+
+// Grid returns the slots of the table that rowspan and colspan are
+// expanded by the table model of HTML. grid[y][x] is the cell covering
+// the slot, or nil for the empty slot. all rows have the same length.
+// the row groups are in the tree order except <tfoot> that is the last
+func (t Table) Grid() [][]*Cell {
+	m := &tableModel{}
+	var foot []*html.Node
+	for c := t.Node.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.DataAtom {
+		case atom.Tr:
+			m.row(c)
+			if !isTableRowSibling(c.NextSibling) {
+				m.endGroup()
+			}
+		case atom.Thead, atom.Tbody:
+			for _, tr := range childElements(c, atom.Tr) {
+				m.row(tr)
+			}
+			m.endGroup()
+		case atom.Tfoot:
+			foot = append(foot, c)
+		}
+	}
+	for _, c := range foot {
+		for _, tr := range childElements(c, atom.Tr) {
+			m.row(tr)
+		}
+		m.endGroup()
+	}
+
+	grid := make([][]*Cell, m.height)
+	for y := range grid {
+		grid[y] = make([]*Cell, m.width)
+		if y < len(m.grid) {
+			copy(grid[y], m.grid[y])
+		}
+	}
+	return grid
+}
+
+// isTableRowSibling returns true if n continues the rows of the table
+// itself, which are the rows out of any row group
+func isTableRowSibling(n *html.Node) bool {
+	for ; n != nil; n = n.NextSibling {
+		if n.Type == html.ElementNode {
+			return n.DataAtom == atom.Tr
+		}
+	}
+	return false
+}
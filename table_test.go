@@ -0,0 +1,89 @@
+package gohtml
+
+import (
+	"strings"
+	"testing"
+)
+
+// gridString returns the grid as the lines of the texts of the cells
+func gridString(grid [][]*Cell) string {
+	var lines []string
+	for _, row := range grid {
+		var cells []string
+		for _, c := range row {
+			if c == nil {
+				cells = append(cells, "-")
+			} else {
+				cells = append(cells, c.TextContent())
+			}
+		}
+		lines = append(lines, strings.Join(cells, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestTable(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<table>
+<caption>cap</caption>
+<tfoot><tr><td>f</td></tr></tfoot>
+<thead><tr><th>h1</th><th colspan="2">h2</th></tr></thead>
+<tbody><tr><td rowspan="2">a</td><td>b</td><td>c</td></tr><tr><td>d</td><td><table><tr><td>nested</td></tr></table></td></tr></tbody>
+<tbody><tr><td>e</td></tr></tbody>
+</table>`))
+	table := doc.QuerySelector("table").Table()
+	if table == nil || doc.Body().Table() != nil {
+		t.Fatal("\nTable is wrong\n")
+	}
+
+	if table.Caption().TextContent() != "cap" || table.Head() == nil || table.Foot() == nil || table.Bodies().Length() != 2 {
+		t.Errorf("\nthe sections are wrong\n")
+	}
+	var rows []string
+	for _, tr := range table.Rows().Nodes {
+		rows = append(rows, (&Element{tr}).FirstElementChild().TextContent())
+	}
+	if actual, expect := strings.Join(rows, " "), "h1 a d e f"; actual != expect {
+		t.Errorf("\ngot : %s, want: %s\n", actual, expect)
+	}
+	if actual := table.Cells().Length(); actual != 9 {
+		t.Errorf("\ngot : %d cells, want: 9\n", actual)
+	}
+
+	grid := table.Grid()
+	expect := "h1 h2 h2\na b c\na d nested\ne - -\nf - -"
+	if actual := gridString(grid); actual != expect {
+		t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, expect)
+	}
+	if c := grid[2][0]; c.Row != 1 || c.Col != 0 || c.RowSpan != 2 || grid[0][2] != grid[0][1] || !grid[0][1].Header() {
+		t.Errorf("\nthe spans are wrong\n")
+	}
+}
+
+func TestTableGrid(t *testing.T) {
+	tests := []struct {
+		input, expect string
+	}{
+		{
+			`<tr><td rowspan="0">a</td><td>b</td></tr><tr><td>c</td></tr><tr><td>d</td></tr>`,
+			"a b\na c\na d",
+		},
+		{
+			`<tr><td colspan="0">a</td><td colspan="x">b</td></tr><tr><td>c</td></tr>`,
+			"a b\nc -",
+		},
+		{
+			`<tr><td rowspan="3">a</td><td>b</td></tr>`,
+			"a b\na -\na -",
+		},
+		{
+			`<tr><td>a</td><td rowspan="2">b</td></tr><tr><td colspan="3">c</td></tr>`,
+			"a b -\nc b c",
+		},
+	}
+	for i, test := range tests {
+		doc, _ := Parse(strings.NewReader("<table>" + test.input + "</table>"))
+		if actual := gridString(doc.QuerySelector("table").Table().Grid()); actual != test.expect {
+			t.Errorf("\n%d: got :\n%s\nwant:\n%s\n", i, actual, test.expect)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+package gohtml
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/saihon/gohtml/attr"
+	"github.com/saihon/gohtml/utils"
+)
+
+// CellText is how the text of a cell is taken
+type CellText int
+
+const (
+	// CellInnerText takes the text rendered like InnerText
+	CellInnerText CellText = iota
+	// CellTextContent takes the text like TextContent,
+	// white space is collapsed unless KeepSpace
+	CellTextContent
+)
+
+// TableOptions is the options to export the table
+type TableOptions struct {
+	// Text is how the text of a cell is taken
+	Text CellText
+	// KeepSpace keeps white space of the text as it is,
+	// otherwise the text is trimmed
+	KeepSpace bool
+	// HeaderRows is the number of the rows of the header. if 0, the
+	// rows of <thead>, or the leading rows that all cells are <th> and
+	// not scope="row" are the header. -1 means the table has no header
+	HeaderRows int
+	// HeaderSeparator joins the texts of the multi-row header.
+	// a space if empty
+	HeaderSeparator string
+	// Attributes is the attributes taken from the cells, like "href".
+	// the value is of the first element in the cell that has the
+	// attribute, including the cell itself. it is the column keyed
+	// by the header and the attribute joined by "@", like "Name@href"
+	Attributes []string
+}
+
+// tableData is the texts of the grid out of the header
+// and the keys of the columns
+type tableData struct {
+	keys   []string
+	rows   [][]string
+	header int
+}
+
+func (o *TableOptions) cellText(n *html.Node) string {
+	if o.Text == CellTextContent {
+		s := utils.Text(n)
+		if o.KeepSpace {
+			return s
+		}
+		return strings.TrimSpace(collapseSpace(s))
+	}
+	s := Element{n}.PlainText(nil)
+	if o.KeepSpace {
+		return s
+	}
+	return strings.TrimSpace(s)
+}
+
+// cellAttribute returns the attribute of the first element in the cell
+func cellAttribute(n *html.Node, key string) string {
+	if a, ok := attr.GetNode(n, key); ok {
+		return a.Val
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			if v := cellAttribute(c, key); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// headerRows returns the number of the header rows of the grid
+func (t Table) headerRows(grid [][]*Cell, opts *TableOptions) int {
+	if opts.HeaderRows < 0 {
+		return 0
+	}
+	if opts.HeaderRows > 0 {
+		return min(opts.HeaderRows, len(grid))
+	}
+	if head := t.Head(); head != nil {
+		rows := 0
+		for _, row := range grid {
+			if !rowIn(row, head.Node) {
+				break
+			}
+			rows++
+		}
+		if rows > 0 {
+			return rows
+		}
+	}
+
+	rows := 0
+	for _, row := range grid {
+		for _, c := range row {
+			if c == nil || !c.Header() {
+				return rows
+			}
+			// the header of the row labels the row, not the columns
+			if scope := strings.ToLower(attr.Get(c.Node, "scope")); scope == "row" || scope == "rowgroup" {
+				return rows
+			}
+		}
+		rows++
+	}
+	return rows
+}
+
+// rowIn returns true if the cells of the row are in the section
+func rowIn(row []*Cell, section *html.Node) bool {
+	for _, c := range row {
+		if c != nil {
+			return c.Node.Parent != nil && c.Node.Parent.Parent == section
+		}
+	}
+	return false
+}
+
+// data returns the texts of the table and the keys of the columns
+func (t Table) data(opts *TableOptions) tableData {
+	if opts == nil {
+		opts = &TableOptions{}
+	}
+	sep := opts.HeaderSeparator
+	if sep == "" {
+		sep = " "
+	}
+	grid := t.Grid()
+	header := t.headerRows(grid, opts)
+
+	d := tableData{header: header}
+	width := 0
+	if len(grid) > 0 {
+		width = len(grid[0])
+	}
+
+	seen := map[string]int{}
+	var keys []string
+	for x := 0; x < width; x++ {
+		var parts []string
+		var prev *Cell
+		for y := 0; y < header; y++ {
+			c := grid[y][x]
+			if c == nil || c == prev {
+				continue
+			}
+			prev = c
+			if s := opts.cellText(c.Node); s != "" && (len(parts) == 0 || parts[len(parts)-1] != s) {
+				parts = append(parts, s)
+			}
+		}
+		key := strings.Join(parts, sep)
+		if key == "" {
+			key = strconv.Itoa(x)
+		}
+		// the same keys are numbered like "Name 2"
+		if seen[key]++; seen[key] > 1 {
+			key += " " + strconv.Itoa(seen[key])
+		}
+		keys = append(keys, key)
+	}
+	d.keys = append(d.keys, keys...)
+	for _, a := range opts.Attributes {
+		for _, k := range keys {
+			d.keys = append(d.keys, k+"@"+a)
+		}
+	}
+
+	texts := map[*Cell]string{}
+	for _, row := range grid[header:] {
+		values := make([]string, 0, len(d.keys))
+		for _, c := range row {
+			if c == nil {
+				values = append(values, "")
+				continue
+			}
+			s, ok := texts[c]
+			if !ok {
+				s = opts.cellText(c.Node)
+				texts[c] = s
+			}
+			values = append(values, s)
+		}
+		for _, a := range opts.Attributes {
+			for _, c := range row {
+				if c == nil {
+					values = append(values, "")
+				} else {
+					values = append(values, cellAttribute(c.Node, a))
+				}
+			}
+		}
+		d.rows = append(d.rows, values)
+	}
+	return d
+}
+
+// Header returns the keys of the columns, which are the texts of the
+// header cells. the columns without the header are keyed by the index
+func (t Table) Header(opts *TableOptions) []string {
+	return t.data(opts).keys
+}
+
+// ToRecords returns the rows out of the header as the maps keyed by
+// the columns. the cells spanning the rows or the columns are repeated
+func (t Table) ToRecords(opts *TableOptions) []map[string]string {
+	d := t.data(opts)
+	records := make([]map[string]string, 0, len(d.rows))
+	for _, row := range d.rows {
+		r := make(map[string]string, len(d.keys))
+		for i, k := range d.keys {
+			r[k] = row[i]
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// ToCSV writes the table as CSV to w. the first line is the keys of
+// the columns if the table has the header
+func (t Table) ToCSV(w io.Writer, opts *TableOptions) error {
+	d := t.data(opts)
+	cw := csv.NewWriter(w)
+	if d.header > 0 {
+		if err := cw.Write(d.keys); err != nil {
+			return err
+		}
+	}
+	return cw.WriteAll(d.rows)
+}
+
+// ToJSON writes the records of ToRecords as JSON array to w.
+// the keys of the objects are in the order of the columns
+func (t Table) ToJSON(w io.Writer, opts *TableOptions) error {
+	d := t.data(opts)
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for i, row := range d.rows {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('{')
+		for j, k := range d.keys {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(k)
+			value, _ := json.Marshal(row[j])
+			b.Write(key)
+			b.WriteByte(':')
+			b.Write(value)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteString("]\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// Unmarshal stores the rows out of the header to v, which is the pointer
+// to the slice of structs. the field is matched with the column by the
+// "table" tag, or the field name. the tag "-" skips the field. the string,
+// bool, number, encoding.TextUnmarshaler and the pointer to them are
+// supported, the empty text leaves the field zero. the nil embedded
+// pointer is allocated to set its fields, unless it is unexported
+func (t Table) Unmarshal(v any, opts *TableOptions) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("gohtml: Unmarshal needs the pointer to a slice, not %T", v)
+	}
+	slice := rv.Elem()
+	elem := slice.Type().Elem()
+	ptr := elem.Kind() == reflect.Pointer
+	if ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("gohtml: Unmarshal needs the slice of structs, not %T", v)
+	}
+
+	d := t.data(opts)
+	index := map[string]int{}
+	for i, k := range d.keys {
+		index[k] = i
+	}
+	type column struct {
+		field []int
+		index int
+	}
+	var columns []column
+	for _, f := range reflect.VisibleFields(elem) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name := f.Tag.Get("table")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if i, ok := index[name]; ok {
+			columns = append(columns, column{f.Index, i})
+		}
+	}
+
+	var errs []error
+	for y, row := range d.rows {
+		item := reflect.New(elem).Elem()
+		for _, c := range columns {
+			if row[c.index] == "" {
+				continue
+			}
+			f, ok := fieldByIndex(item, c.field)
+			if !ok {
+				continue
+			}
+			if err := setCell(f, row[c.index]); err != nil {
+				errs = append(errs, fmt.Errorf("gohtml: row %d column %q: %w", y, d.keys[c.index], err))
+			}
+		}
+		if ptr {
+			item = item.Addr()
+		}
+		slice.Set(reflect.Append(slice, item))
+	}
+	return errors.Join(errs...)
+}
+
+// fieldByIndex returns the nested field of v like FieldByIndex, and
+// allocates the nil embedded pointers on the way. it returns false if
+// the embedded pointer can not be set
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setCell sets the text of the cell to the field
+func setCell(f reflect.Value, s string) error {
+	if s == "" {
+		return nil
+	}
+	if f.Kind() == reflect.Pointer {
+		p := reflect.New(f.Type().Elem())
+		if err := setCell(p.Elem(), s); err != nil {
+			return err
+		}
+		f.Set(p)
+		return nil
+	}
+	if f.Addr().Type().Implements(textUnmarshalerType) {
+		return f.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(s, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(x)
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package gohtml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testScores = `<table>
+<thead>
+<tr><th rowspan="2">Name</th><th colspan="2">Score</th></tr>
+<tr><th>Q1</th><th>Q2</th></tr>
+</thead>
+<tbody>
+<tr><th scope="row"><a href="/alice">Alice</a></th><td> 1 </td><td>2</td></tr>
+<tr><th scope="row"><a href="/bob">Bob</a></th><td>3</td><td></td></tr>
+</tbody>
+</table>`
+
+func testTable(t *testing.T, s string) *Table {
+	doc, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc.QuerySelector("table").Table()
+}
+
+func TestTableRecords(t *testing.T) {
+	table := testTable(t, testScores)
+
+	opts := &TableOptions{Attributes: []string{"href"}}
+	keys := []string{"Name", "Score Q1", "Score Q2", "Name@href", "Score Q1@href", "Score Q2@href"}
+	if actual := table.Header(opts); !reflect.DeepEqual(actual, keys) {
+		t.Errorf("\ngot : %q\nwant: %q\n", actual, keys)
+	}
+	records := table.ToRecords(opts)
+	expect := []map[string]string{
+		{"Name": "Alice", "Score Q1": "1", "Score Q2": "2", "Name@href": "/alice", "Score Q1@href": "", "Score Q2@href": ""},
+		{"Name": "Bob", "Score Q1": "3", "Score Q2": "", "Name@href": "/bob", "Score Q1@href": "", "Score Q2@href": ""},
+	}
+	if !reflect.DeepEqual(records, expect) {
+		t.Errorf("\ngot : %v\nwant: %v\n", records, expect)
+	}
+
+	records = table.ToRecords(&TableOptions{Text: CellTextContent, KeepSpace: true, HeaderSeparator: "/"})
+	if actual := records[0]["Score/Q1"]; actual != " 1 " {
+		t.Errorf("\ngot : %q, want: %q\n", actual, " 1 ")
+	}
+
+	tests := []struct {
+		input  string
+		opts   *TableOptions
+		expect string
+	}{
+		{testScores, nil, "Name,Score Q1,Score Q2\nAlice,1,2\nBob,3,\n"},
+		{testScores, &TableOptions{HeaderRows: 1}, "Name,Score,Score 2\nName,Q1,Q2\nAlice,1,2\nBob,3,\n"},
+		{`<table><tr><th>A</th><th>A</th></tr><tr><th scope="row">x</th><td>1</td></tr></table>`, nil, "A,A 2\nx,1\n"},
+		{`<table><tr><th scope="row">x</th><td>"1"</td></tr></table>`, nil, "x,\"\"\"1\"\"\"\n"},
+		{`<table><tr><td>1</td><td colspan="2">2</td></tr><tr><td>3</td></tr></table>`, &TableOptions{HeaderRows: -1}, "1,2,2\n3,,\n"},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := testTable(t, tt.input).ToCSV(&b, tt.opts); err != nil {
+			t.Fatal(err)
+		}
+		if actual := b.String(); actual != tt.expect {
+			t.Errorf("\ngot :\n%s\nwant:\n%s\n", actual, tt.expect)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := table.ToJSON(&b, nil); err != nil {
+		t.Fatal(err)
+	}
+	expectJSON := `[{"Name":"Alice","Score Q1":"1","Score Q2":"2"},{"Name":"Bob","Score Q1":"3","Score Q2":""}]` + "\n"
+	if actual := b.String(); actual != expectJSON {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expectJSON)
+	}
+}
+
+func TestTableUnmarshal(t *testing.T) {
+	table := testTable(t, testScores)
+
+	type score struct {
+		Name string
+		Link string `table:"Name@href"`
+		Q1   int    `table:"Score Q1"`
+		Q2   *int   `table:"Score Q2"`
+		Skip string `table:"-"`
+	}
+	var scores []score
+	if err := table.Unmarshal(&scores, &TableOptions{Attributes: []string{"href"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 2 || scores[0].Name != "Alice" || scores[0].Link != "/alice" || scores[0].Q1 != 1 ||
+		scores[0].Q2 == nil || *scores[0].Q2 != 2 || scores[1].Q1 != 3 || scores[1].Q2 != nil {
+		t.Errorf("\ngot : %+v\n", scores)
+	}
+
+	var invalid []*struct {
+		Name bool
+		Q1   float64 `table:"Score Q1"`
+	}
+	err := table.Unmarshal(&invalid, nil)
+	if err == nil || !strings.Contains(err.Error(), `row 1 column "Name"`) || len(invalid) != 2 || invalid[1].Q1 != 3 {
+		t.Errorf("\ngot : %v, %v\n", err, invalid)
+	}
+	if err := table.Unmarshal(scores, nil); err == nil {
+		t.Errorf("\nthe slice must be an error\n")
+	}
+}
+
+func TestTableUnmarshalEmbedded(t *testing.T) {
+	table := testTable(t, `<table><tr><th>ID</th><th>Name</th><th>Hidden</th></tr><tr><td>1</td><td>a</td><td>h</td></tr><tr><td></td><td>b</td><td></td></tr></table>`)
+
+	type Base struct {
+		ID string
+	}
+	type base struct {
+		Hidden string
+	}
+	var rows []struct {
+		*Base
+		*base
+		Name string
+	}
+	if err := table.Unmarshal(&rows, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Base == nil || rows[0].ID != "1" || rows[0].Name != "a" ||
+		rows[0].base != nil || rows[1].Base != nil || rows[1].Name != "b" {
+		t.Errorf("\ngot : %+v\n", rows)
+	}
+}
@@ -0,0 +1,360 @@
+package gohtml
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/saihon/gohtml/attr"
+	"github.com/saihon/gohtml/find"
+)
+
+// SetURL sets the URL of the document, which is the base URL
+// unless the document has <base href>. nil clears the URL.
+// the URL is kept until Release of the document
+func (d Document) SetURL(u *url.URL) {
+	if u == nil {
+		if data := lookupDocData(d.Node); data != nil {
+			docMu.Lock()
+			data.url = nil
+			docMu.Unlock()
+		}
+		return
+	}
+	c := *u
+	data := ensureDocData(d.Node)
+	docMu.Lock()
+	data.url = &c
+	docMu.Unlock()
+}
+
+// URL returns the copy of the URL of the document, or nil if not set
+func (d Document) URL() *url.URL {
+	return documentURL(d.Node)
+}
+
+func documentURL(n *html.Node) *url.URL {
+	data := lookupDocData(n)
+	if data == nil {
+		return nil
+	}
+	docMu.Lock()
+	defer docMu.Unlock()
+	if data.url == nil {
+		return nil
+	}
+	c := *data.url
+	return &c
+}
+
+// BaseURI returns the base URL of the document, which is the href of
+// the first <base> that has it resolved against the URL of the document,
+// or the URL of the document. it returns nil if neither is given
+func (d Document) BaseURI() *url.URL {
+	return baseURI(d.Node)
+}
+
+// BaseURI returns the base URL of the document the element belongs to
+func (e Element) BaseURI() *url.URL {
+	return baseURI(e.Node)
+}
+
+func baseURI(n *html.Node) *url.URL {
+	doc := documentURL(n)
+	base := find.First(rootOf(n), func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.DataAtom == atom.Base && attr.Has(n, "href")
+	})
+	if base == nil {
+		return doc
+	}
+	u, err := resolveURL(doc, attr.Get(base, "href"))
+	if err != nil {
+		return doc
+	}
+	return u
+}
+
+// resolveURL parses the reference and resolves it against base.
+// base can be nil, then the reference is returned as it is parsed
+func resolveURL(base *url.URL, ref string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return nil, err
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	normalizeURL(u)
+	return u, nil
+}
+
+// defaultPorts is the default ports of the special schemes
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+// normalizeURL lowercases the scheme and the host,
+// and drops the default port of the scheme
+func normalizeURL(u *url.URL) {
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); port != "" && defaultPorts[u.Scheme] == port {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+}
+
+// hrefURL returns the URL of the href attribute resolved against the
+// base URL, or nil if the element has no href or it cannot be parsed
+func hrefURL(n *html.Node) *url.URL {
+	a, ok := attr.GetNode(n, "href")
+	if !ok {
+		return nil
+	}
+	base := baseURI(n)
+	if n.DataAtom == atom.Base {
+		// <base> itself is resolved against the URL of the document
+		base = documentURL(n)
+	}
+	u, err := resolveURL(base, a.Val)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// Href set or get the href attribute like href of JavaScript.
+// getting returns the URL resolved against the base URL, or the
+// attribute as it is if it cannot be parsed
+func (e Element) Href(href ...string) string {
+	if href != nil {
+		e.SetAttribute("href", strings.Join(href, ""))
+	}
+	if u := hrefURL(e.Node); u != nil {
+		return u.String()
+	}
+	return attr.Get(e.Node, "href")
+}
+
+// updateHref modifies the URL of the href attribute by fn and
+// writes it back. nothing is done if the href cannot be parsed
+func (e Element) updateHref(fn func(u *url.URL) bool) {
+	u := hrefURL(e.Node)
+	if u == nil {
+		return
+	}
+	if fn(u) {
+		normalizeURL(u)
+		e.SetAttribute("href", u.String())
+	}
+}
+
+// Protocol set or get the scheme of the href with the trailing ":"
+func (e Element) Protocol(protocol ...string) string {
+	if protocol != nil {
+		e.updateHref(func(u *url.URL) bool {
+			s, _, _ := strings.Cut(strings.Join(protocol, ""), ":")
+			if !validScheme(s) {
+				return false
+			}
+			u.Scheme = s
+			return true
+		})
+	}
+	if u := hrefURL(e.Node); u != nil {
+		return u.Scheme + ":"
+	}
+	return ":"
+}
+
+// validScheme returns true if s is the scheme of URL
+func validScheme(s string) bool {
+	for i, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && ('0' <= r && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Host set or get the host and the port of the href
+func (e Element) Host(host ...string) string {
+	if host != nil {
+		e.updateHref(func(u *url.URL) bool {
+			if u.Opaque != "" {
+				return false
+			}
+			h := strings.Join(host, "")
+			if i := strings.IndexAny(h, "/?#"); i >= 0 {
+				h = h[:i]
+			}
+			if h == "" {
+				return false
+			}
+			u.Host = h
+			return true
+		})
+	}
+	if u := hrefURL(e.Node); u != nil {
+		return u.Host
+	}
+	return ""
+}
+
+// Hostname set or get the host of the href without the port
+func (e Element) Hostname(hostname ...string) string {
+	if hostname != nil {
+		e.updateHref(func(u *url.URL) bool {
+			if u.Opaque != "" {
+				return false
+			}
+			h := strings.Join(hostname, "")
+			if i := strings.IndexAny(h, ":/?#"); i >= 0 && !strings.HasPrefix(h, "[") {
+				h = h[:i]
+			}
+			if h == "" {
+				return false
+			}
+			if port := u.Port(); port != "" {
+				h += ":" + port
+			}
+			u.Host = h
+			return true
+		})
+	}
+	if u := hrefURL(e.Node); u != nil {
+		h := u.Hostname()
+		if strings.Contains(h, ":") {
+			h = "[" + h + "]"
+		}
+		return h
+	}
+	return ""
+}
+
+// Port set or get the port of the href. the empty port is the
+// default port of the scheme, and setting it removes the port
+func (e Element) Port(port ...string) string {
+	if port != nil {
+		e.updateHref(func(u *url.URL) bool {
+			if u.Opaque != "" || u.Host == "" {
+				return false
+			}
+			p := strings.Join(port, "")
+			// the leading digits are taken
+			i := 0
+			for i < len(p) && '0' <= p[i] && p[i] <= '9' {
+				i++
+			}
+			host := u.Hostname()
+			if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+			if p != "" {
+				n, err := strconv.Atoi(p[:i])
+				if err != nil || n > 65535 {
+					return false
+				}
+				host += ":" + strconv.Itoa(n)
+			}
+			u.Host = host
+			return true
+		})
+	}
+	if u := hrefURL(e.Node); u != nil {
+		return u.Port()
+	}
+	return ""
+}
+
+// Pathname set or get the path of the href
+func (e Element) Pathname(pathname ...string) string {
+	if pathname != nil {
+		e.updateHref(func(u *url.URL) bool {
+			if u.Opaque != "" {
+				return false
+			}
+			p := strings.Join(pathname, "")
+			if u.Host != "" && !strings.HasPrefix(p, "/") {
+				p = "/" + p
+			}
+			u.Path, u.RawPath = p, ""
+			return true
+		})
+	}
+	if u := hrefURL(e.Node); u != nil {
+		if u.Opaque != "" {
+			return u.Opaque
+		}
+		return u.EscapedPath()
+	}
+	return ""
+}
+
+// Search set or get the query of the href with the leading "?"
+func (e Element) Search(search ...string) string {
+	if search != nil {
+		e.updateHref(func(u *url.URL) bool {
+			u.RawQuery = escapeQuery(strings.TrimPrefix(strings.Join(search, ""), "?"))
+			u.ForceQuery = false
+			return true
+		})
+	}
+	if u := hrefURL(e.Node); u != nil && u.RawQuery != "" {
+		return "?" + u.RawQuery
+	}
+	return ""
+}
+
+// escapeQuery percent-encodes the query by the query percent-encode set
+// of the URL standard, which keeps "%" and the most of the punctuations
+func escapeQuery(q string) string {
+	var b strings.Builder
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		if c <= ' ' || c >= 0x7f || c == '"' || c == '#' || c == '<' || c == '>' || c == '\'' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// Hash set or get the fragment of the href with the leading "#"
+func (e Element) Hash(hash ...string) string {
+	if hash != nil {
+		e.updateHref(func(u *url.URL) bool {
+			u.Fragment = strings.TrimPrefix(strings.Join(hash, ""), "#")
+			u.RawFragment = ""
+			return true
+		})
+	}
+	if u := hrefURL(e.Node); u != nil && u.Fragment != "" {
+		return "#" + u.EscapedFragment()
+	}
+	return ""
+}
+
+// Origin returns the origin of the href, the scheme, the host and the
+// port. it is "null" for the URL that has no tuple origin like data:
+func (e Element) Origin() string {
+	u := hrefURL(e.Node)
+	if u == nil || u.Host == "" {
+		return "null"
+	}
+	if _, ok := defaultPorts[u.Scheme]; !ok {
+		return "null"
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,115 @@
+package gohtml
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDocumentURL(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<base href="/sub/"><a id="a" href="page?x=1#top">a</a><form action="post"></form>`))
+	defer doc.Release()
+	if doc.URL() != nil || doc.BaseURI().String() != "/sub/" {
+		t.Errorf("\nthe document must have no URL\n")
+	}
+	if actual := doc.GetElementById("a").Href(); actual != "/sub/page?x=1#top" {
+		t.Errorf("\ngot : %s\n", actual)
+	}
+
+	u, _ := url.Parse("https://host.test/dir/index.html")
+	doc.SetURL(u)
+	u.Host = "changed"
+	if actual := doc.URL().String(); actual != "https://host.test/dir/index.html" {
+		t.Errorf("\ngot : %s\n", actual)
+	}
+	if actual := doc.GetElementById("a").BaseURI().String(); actual != "https://host.test/sub/" {
+		t.Errorf("\ngot : %s\n", actual)
+	}
+	if actual := doc.QuerySelector("base").Href(); actual != "https://host.test/sub/" {
+		t.Errorf("\ngot : %s\n", actual)
+	}
+	action, err := doc.QuerySelector("form").Form().Action(nil, nil)
+	if err != nil || action.String() != "https://host.test/sub/post" {
+		t.Errorf("\ngot : %v, %v\n", action, err)
+	}
+
+	doc.QuerySelector("base").Remove()
+	if actual := doc.BaseURI().String(); actual != "https://host.test/dir/index.html" {
+		t.Errorf("\ngot : %s\n", actual)
+	}
+	doc.SetURL(nil)
+	if doc.URL() != nil {
+		t.Errorf("\nthe URL must be cleared\n")
+	}
+
+	doc.SetURL(u)
+	doc.Release()
+	if doc.URL() != nil || lookupDocData(doc.Node) != nil {
+		t.Errorf("\nthe URL must be released\n")
+	}
+}
+
+func TestHref(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<a id="a" href="page?x=1#top">a</a>
+<map><area id="b" href="HTTPS://Example.COM:443/p"></map>
+<a id="c" href="mailto:x@y">c</a>
+<a id="d">d</a>`))
+	defer doc.Release()
+	u, _ := url.Parse("https://host.test/dir/")
+	doc.SetURL(u)
+
+	a := doc.GetElementById("a")
+	tests := []struct {
+		actual, expect string
+	}{
+		{a.Href(), "https://host.test/dir/page?x=1#top"},
+		{a.Protocol(), "https:"},
+		{a.Host(), "host.test"},
+		{a.Pathname(), "/dir/page"},
+		{a.Search(), "?x=1"},
+		{a.Hash(), "#top"},
+		{a.Origin(), "https://host.test"},
+		{doc.GetElementById("b").Href(), "https://example.com/p"},
+		{doc.GetElementById("b").Port(), ""},
+		{doc.GetElementById("c").Protocol(), "mailto:"},
+		{doc.GetElementById("c").Pathname(), "x@y"},
+		{doc.GetElementById("c").Origin(), "null"},
+		{doc.GetElementById("d").Href(), ""},
+		{doc.GetElementById("d").Protocol(), ":"},
+	}
+	for i, tt := range tests {
+		if tt.actual != tt.expect {
+			t.Errorf("\n%d: got : %s, want: %s\n", i, tt.actual, tt.expect)
+		}
+	}
+
+	setters := []struct {
+		set    func()
+		expect string
+	}{
+		{func() { a.Protocol("http:") }, "http://host.test/dir/page?x=1#top"},
+		{func() { a.Protocol("1x") }, "http://host.test/dir/page?x=1#top"},
+		{func() { a.Host("other:8080/ignored") }, "http://other:8080/dir/page?x=1#top"},
+		{func() { a.Port("80") }, "http://other/dir/page?x=1#top"},
+		{func() { a.Port("81abc") }, "http://other:81/dir/page?x=1#top"},
+		{func() { a.Hostname("[::1]") }, "http://[::1]:81/dir/page?x=1#top"},
+		{func() { a.Port("") }, "http://[::1]/dir/page?x=1#top"},
+		{func() { a.Pathname("a b") }, "http://[::1]/a%20b?x=1#top"},
+		{func() { a.Search("?q=a b") }, "http://[::1]/a%20b?q=a%20b#top"},
+		{func() { a.Hash("") }, "http://[::1]/a%20b?q=a%20b"},
+		{func() { a.Search("") }, "http://[::1]/a%20b"},
+		{func() { a.Href("/x") }, "https://host.test/x"},
+	}
+	for i, tt := range setters {
+		tt.set()
+		if actual := a.Href(); actual != tt.expect {
+			t.Errorf("\n%d: got : %s, want: %s\n", i, actual, tt.expect)
+		}
+	}
+	if actual := a.GetAttribute("href"); actual != "/x" {
+		t.Errorf("\ngot : %s, want: /x\n", actual)
+	}
+	if a.Hostname() != "host.test" || a.Hash("top") != "#top" || a.GetAttribute("href") != "https://host.test/x#top" {
+		t.Errorf("\nthe setters must write the resolved URL back\n")
+	}
+}
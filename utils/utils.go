@@ -209,10 +209,11 @@ func Sibling(n *html.Node) []*html.Node {
 // Clone cloneNode
 func Clone(n *html.Node) *html.Node {
 	node := &html.Node{
-		Type:     n.Type,
-		DataAtom: n.DataAtom,
-		Data:     n.Data,
-		Attr:     make([]html.Attribute, len(n.Attr)),
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+		Attr:      make([]html.Attribute, len(n.Attr)),
 	}
 	copy(node.Attr, n.Attr)
 	return node
@@ -289,6 +290,48 @@ func WrapAll(nodes []*html.Node, wrapper *html.Node) error {
 	return nil
 }
 
+// Path returns the indexes of the child nodes from root to n,
+// all types of the nodes are counted. returns nil if n is not in root
+func Path(root, n *html.Node) []int {
+	var path []int
+	for ; n != root; n = n.Parent {
+		if n == nil {
+			return nil
+		}
+		i := 0
+		for c := n.PrevSibling; c != nil; c = c.PrevSibling {
+			i++
+		}
+		path = append(path, i)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	if path == nil {
+		path = []int{}
+	}
+	return path
+}
+
+// NodeAt returns the node at the path from root, or nil if not exist
+func NodeAt(root *html.Node, path []int) *html.Node {
+	n := root
+	for _, i := range path {
+		if i < 0 {
+			return nil
+		}
+		c := n.FirstChild
+		for ; c != nil && i > 0; i-- {
+			c = c.NextSibling
+		}
+		if c == nil {
+			return nil
+		}
+		n = c
+	}
+	return n
+}
+
 // Remove
 func Remove(n *html.Node) {
 	if p := Parent(n); p != nil {
@@ -304,6 +304,32 @@ func TestWrapAll(t *testing.T) {
 	}
 }
 
+func TestPath(t *testing.T) {
+	s := `<html><head></head><body>text<div><p></p><span></span></div></body></html>`
+
+	doc, _ := html.Parse(strings.NewReader(s))
+	body, err := getbody(doc)
+	if err != nil {
+		t.Errorf("\n%v\n", err)
+		return
+	}
+
+	span := body.LastChild.LastChild
+	path := Path(doc, span)
+	if expect := []int{0, 1, 1, 1}; !reflect.DeepEqual(path, expect) {
+		t.Errorf("\ngot : %v, want: %v\n", path, expect)
+	}
+	if n := NodeAt(doc, path); n != span {
+		t.Errorf("\ngot : %v, want: %v\n", n, span)
+	}
+	if n := NodeAt(doc, []int{0, 1, 5}); n != nil {
+		t.Errorf("\ngot : %v, want: nil\n", n)
+	}
+	if path := Path(body, doc); path != nil {
+		t.Errorf("\ngot : %v, want: nil\n", path)
+	}
+}
+
 func TestRemove(t *testing.T) {
 	s := `<html><head></head><body><div><p></p><span></span></div></body></html>`
 
@@ -0,0 +1,364 @@
+package gohtml
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/saihon/gohtml/attr"
+)
+
+// ValidityState is the validity of a form control like ValidityState of
+// JavaScript. unlike browsers, TooLong and TooShort are checked for the
+// default values too, and BadInput is true if the value attribute is not
+// valid for the input type, which browsers drop silently
+type ValidityState struct {
+	ValueMissing    bool
+	TypeMismatch    bool
+	PatternMismatch bool
+	TooLong         bool
+	TooShort        bool
+	RangeUnderflow  bool
+	RangeOverflow   bool
+	StepMismatch    bool
+	BadInput        bool
+	CustomError     bool
+	Valid           bool
+}
+
+// String returns the names of the failed constraints, or "valid"
+func (v ValidityState) String() string {
+	var list []string
+	for _, c := range []struct {
+		failed bool
+		name   string
+	}{
+		{v.ValueMissing, "valueMissing"},
+		{v.TypeMismatch, "typeMismatch"},
+		{v.PatternMismatch, "patternMismatch"},
+		{v.TooLong, "tooLong"},
+		{v.TooShort, "tooShort"},
+		{v.RangeUnderflow, "rangeUnderflow"},
+		{v.RangeOverflow, "rangeOverflow"},
+		{v.StepMismatch, "stepMismatch"},
+		{v.BadInput, "badInput"},
+		{v.CustomError, "customError"},
+	} {
+		if c.failed {
+			list = append(list, c.name)
+		}
+	}
+	if len(list) == 0 {
+		return "valid"
+	}
+	return strings.Join(list, " ")
+}
+
+// InvalidControl is the control that does not satisfy its constraints
+type InvalidControl struct {
+	Element  *Element
+	Validity ValidityState
+	// Message is the custom validity message, if set
+	Message string
+}
+
+// WillValidate returns true if the element is a candidate for the
+// constraint validation, which is not disabled, read-only or hidden
+func (e Element) WillValidate() bool {
+	n := e.Node
+	if n.Type != html.ElementNode || inDatalist(n) || disabledControl(n) {
+		return false
+	}
+	switch n.DataAtom {
+	case atom.Input:
+		switch inputType(n) {
+		case "hidden", "reset", "button":
+			return false
+		}
+		return !attr.Has(n, "readonly")
+	case atom.Textarea:
+		return !attr.Has(n, "readonly")
+	case atom.Select:
+		return true
+	case atom.Button:
+		return submitButton(n)
+	}
+	return false
+}
+
+// SetCustomValidity sets the custom validity message.
+// empty message clears the custom error. the message is kept
+// until Release of the document, and is not freed without Release
+func (e Element) SetCustomValidity(message string) {
+	ensureState(e.Node).customError = message
+}
+
+// ValidationMessage returns the custom validity message
+func (e Element) ValidationMessage() string {
+	if s := stateOf(e.Node); s != nil {
+		return s.customError
+	}
+	return ""
+}
+
+// CheckValidity returns true if the element satisfies its constraints
+// or is not a candidate for the constraint validation
+func (e Element) CheckValidity() bool {
+	return e.Validity().Valid
+}
+
+// Validity returns the validity state of the form control
+func (e Element) Validity() ValidityState {
+	var v ValidityState
+	if e.WillValidate() {
+		n := e.Node
+		v.CustomError = e.ValidationMessage() != ""
+		switch n.DataAtom {
+		case atom.Input:
+			validateInput(n, &v)
+		case atom.Textarea:
+			value := controlValue(n)
+			v.ValueMissing = attr.Has(n, "required") && value == ""
+			validateLength(n, value, &v)
+		case atom.Select:
+			v.ValueMissing = attr.Has(n, "required") && selectMissing(n)
+		}
+	}
+	v.Valid = v == ValidityState{}
+	return v
+}
+
+// CheckValidity returns the controls of the form that do not satisfy
+// their constraints in the tree order, or nil if all are valid
+func (f Form) CheckValidity() []InvalidControl {
+	var list []InvalidControl
+	for _, n := range f.listed() {
+		e := &Element{n}
+		if v := e.Validity(); !v.Valid {
+			list = append(list, InvalidControl{Element: e, Validity: v, Message: e.ValidationMessage()})
+		}
+	}
+	return list
+}
+
+// selectMissing returns true if no option is selected,
+// or only the placeholder label option is selected
+func selectMissing(sel *html.Node) bool {
+	selected := selectedOptions(sel)
+	for _, o := range selected {
+		if !placeholderOption(sel, o) {
+			return false
+		}
+	}
+	return true
+}
+
+// placeholderOption returns true if the option is the placeholder
+// label option of the required drop-down box
+func placeholderOption(sel, o *html.Node) bool {
+	if attr.Has(sel, "multiple") || displaySize(sel) != 1 || o.Parent != sel {
+		return false
+	}
+	list := options(sel)
+	return len(list) > 0 && list[0] == o && optionValue(o) == ""
+}
+
+func validateInput(n *html.Node, v *ValidityState) {
+	t := inputType(n)
+	value := controlValue(n)
+
+	if s := stateOf(n); s == nil || !s.dirtyValue {
+		if raw := attr.Get(n, "value"); raw != "" {
+			switch t {
+			case "color":
+				v.BadInput = !reColor.MatchString(raw)
+			case "number", "range":
+				_, ok := parseNumber(raw)
+				v.BadInput = !ok
+			case "date", "month", "week", "time", "datetime-local":
+				_, ok := parseTime(t, raw)
+				v.BadInput = !ok
+			}
+		}
+	}
+
+	if attr.Has(n, "required") {
+		switch t {
+		case "checkbox":
+			v.ValueMissing = !checkedness(n)
+		case "radio":
+			v.ValueMissing = true
+			for _, r := range radioGroup(n) {
+				if checkedness(r) {
+					v.ValueMissing = false
+				}
+			}
+		case "file":
+			v.ValueMissing = true
+		case "range", "color", "submit", "image":
+		default:
+			v.ValueMissing = value == ""
+		}
+	} else if t == "radio" {
+		// a required radio button makes its group required
+		checked, required := false, false
+		for _, r := range radioGroup(n) {
+			checked = checked || checkedness(r)
+			required = required || attr.Has(r, "required")
+		}
+		v.ValueMissing = required && !checked
+	}
+	if value == "" {
+		return
+	}
+
+	values := []string{value}
+	switch t {
+	case "email":
+		if attr.Has(n, "multiple") {
+			values = strings.Split(value, ",")
+			for i := range values {
+				values[i] = strings.TrimSpace(values[i])
+			}
+		}
+		for _, s := range values {
+			v.TypeMismatch = v.TypeMismatch || !validEmail(s)
+		}
+	case "url":
+		u, err := url.Parse(value)
+		v.TypeMismatch = err != nil || !u.IsAbs()
+	}
+
+	switch t {
+	case "text", "search", "url", "tel", "email", "password":
+		if re := patternOf(n); re != nil {
+			for _, s := range values {
+				v.PatternMismatch = v.PatternMismatch || !re.MatchString(s)
+			}
+		}
+		validateLength(n, value, v)
+	case "number", "range", "date", "month", "week", "time", "datetime-local":
+		validateRange(n, t, value, v)
+	}
+}
+
+// reEmail is the valid e-mail address of HTML
+var reEmail = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+func validEmail(s string) bool {
+	return reEmail.MatchString(s)
+}
+
+// patternOf returns the compiled pattern attribute, or nil if it is
+// absent or cannot be compiled by the regexp package
+func patternOf(n *html.Node) *regexp.Regexp {
+	p, ok := attr.GetNode(n, "pattern")
+	if !ok {
+		return nil
+	}
+	re, err := regexp.Compile(`^(?:` + p.Val + `)$`)
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// validateLength checks minlength and maxlength in UTF-16 code units
+func validateLength(n *html.Node, value string, v *ValidityState) {
+	length := len(utf16.Encode([]rune(value)))
+	if max, err := strconv.Atoi(attr.Get(n, "maxlength")); err == nil && max >= 0 {
+		v.TooLong = length > max
+	}
+	if min, err := strconv.Atoi(attr.Get(n, "minlength")); err == nil && min >= 0 && length > 0 {
+		v.TooShort = length < min
+	}
+}
+
+// numberOf returns the numeric representation of the value for the type.
+// the dates and the times are milliseconds, the months are months since 1970
+func numberOf(t, s string) (float64, bool) {
+	switch t {
+	case "number", "range":
+		return parseNumber(s)
+	}
+	tm, ok := parseTime(t, s)
+	if !ok {
+		return 0, false
+	}
+	switch t {
+	case "month":
+		return float64((tm.Year()-1970)*12 + int(tm.Month()) - 1), true
+	case "time":
+		// the time is parsed at year 0, which is too far for time.Duration
+		return float64(tm.Hour()*3600000+tm.Minute()*60000+tm.Second()*1000) + float64(tm.Nanosecond())/1e6, true
+	}
+	return float64(tm.UnixMilli()), true
+}
+
+// stepScales is the step scale factor and the default step of the types
+var stepScales = map[string][2]float64{
+	"number":         {1, 1},
+	"range":          {1, 1},
+	"date":           {86400000, 1},
+	"month":          {1, 1},
+	"week":           {604800000, 1},
+	"time":           {1000, 60},
+	"datetime-local": {1000, 60},
+}
+
+// validateRange checks min, max and step of the number, the date and the time
+func validateRange(n *html.Node, t, value string, v *ValidityState) {
+	x, ok := numberOf(t, value)
+	if !ok {
+		return
+	}
+	min, hasMin := numberOf(t, attr.Get(n, "min"))
+	max, hasMax := numberOf(t, attr.Get(n, "max"))
+	if t == "range" {
+		if !hasMin {
+			min, hasMin = 0, true
+		}
+		if !hasMax {
+			max, hasMax = 100, true
+		}
+	}
+	if t == "time" && hasMin && hasMax && max < min {
+		// the reversed range wraps around midnight
+		if x < min && x > max {
+			v.RangeUnderflow, v.RangeOverflow = true, true
+		}
+	} else {
+		v.RangeUnderflow = hasMin && x < min
+		v.RangeOverflow = hasMax && x > max
+	}
+
+	scale := stepScales[t]
+	step := scale[1]
+	if s := strings.TrimSpace(attr.Get(n, "step")); strings.EqualFold(s, "any") {
+		return
+	} else if f, ok := parseNumber(s); ok && f > 0 {
+		step = f
+		if t == "month" {
+			step = math.Round(step)
+		}
+	}
+	step *= scale[0]
+
+	base := 0.0
+	if hasMin {
+		base = min
+	} else if f, ok := numberOf(t, attr.Get(n, "value")); ok {
+		base = f
+	} else if t == "week" {
+		// the week of the epoch starts on Monday 1969-12-29
+		base = -259200000
+	}
+	q := (x - base) / step
+	v.StepMismatch = math.Abs(q-math.Round(q)) > 1e-9*math.Max(1, math.Abs(q))
+}
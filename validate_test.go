@@ -0,0 +1,89 @@
+package gohtml
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidity(t *testing.T) {
+	tests := []struct {
+		input, expect string
+	}{
+		{`<input required>`, "valueMissing"},
+		{`<input required value="x">`, "valid"},
+		{`<input required disabled>`, "valid"},
+		{`<input required readonly>`, "valid"},
+		{`<input type="checkbox" required>`, "valueMissing"},
+		{`<input type="email" value="a@b.example">`, "valid"},
+		{`<input type="email" value="a@b@c">`, "typeMismatch"},
+		{`<input type="email" multiple value="a@b.example, c@d">`, "valid"},
+		{`<input type="url" value="/relative">`, "typeMismatch"},
+		{`<input pattern="[a-z]+" value="abc1">`, "patternMismatch"},
+		{`<input pattern="[a-z]+|x" value="abc">`, "valid"},
+		{`<input maxlength="3" value="abcd">`, "tooLong"},
+		{`<input minlength="3" value="ab">`, "tooShort"},
+		{`<input maxlength="2" value="😀">`, "valid"},
+		{`<textarea maxlength="2">😀x</textarea>`, "tooLong"},
+		{`<input type="number" min="1" max="10" value="0">`, "rangeUnderflow"},
+		{`<input type="number" min="1" max="10" value="11">`, "rangeOverflow"},
+		{`<input type="number" min="0" value="1.5">`, "stepMismatch"},
+		{`<input type="number" step="0.1" value="0.3">`, "valid"},
+		{`<input type="number" step="any" value="1.25">`, "valid"},
+		{`<input type="number" min="1" step="2" value="4">`, "stepMismatch"},
+		{`<input type="number" value="abc">`, "badInput"},
+		{`<input type="color" value="#ABCDEF">`, "valid"},
+		{`<input type="date" min="2024-01-01" value="2023-12-31">`, "rangeUnderflow"},
+		{`<input type="date" step="7" min="2024-01-01" value="2024-01-08">`, "valid"},
+		{`<input type="time" min="00:00" value="10:30:15">`, "stepMismatch"},
+		{`<input type="time" min="22:00" max="02:00" value="23:00">`, "valid"},
+		{`<input type="time" min="22:00" max="02:00" value="12:00">`, "rangeUnderflow rangeOverflow"},
+		{`<input type="month" min="2024-03" value="2024-02">`, "rangeUnderflow"},
+		{`<input type="week" value="2024-W10">`, "valid"},
+		{`<input type="week" value="2024-W60">`, "badInput"},
+		{`<input type="range" value="150">`, "rangeOverflow"},
+		{`<select required><option value="">choose</option><option>a</option></select>`, "valueMissing"},
+		{`<select required><option value="">choose</option><option selected>a</option></select>`, "valid"},
+		{`<select required multiple><option>a</option></select>`, "valueMissing"},
+	}
+	for i, test := range tests {
+		doc, _ := Parse(strings.NewReader("<form>" + test.input + "</form>"))
+		e := doc.Form().Get(0).Form().FirstElementChild()
+		if actual := e.Validity().String(); actual != test.expect {
+			t.Errorf("\n%d: %s\ngot : %s, want: %s\n", i, test.input, actual, test.expect)
+		}
+	}
+}
+
+func TestFormCheckValidity(t *testing.T) {
+	doc, _ := Parse(strings.NewReader(`<form>
+<input name="a" type="radio" required>
+<input name="a" type="radio">
+<input name="b" required value="ok">
+<input name="c" type="number" max="5" value="3">
+<button>submit</button>
+</form>`))
+	form := doc.Form().Get(0).Form()
+
+	list := form.CheckValidity()
+	var names []string
+	for _, c := range list {
+		names = append(names, c.Element.GetAttribute("name")+":"+c.Validity.String())
+	}
+	expect := "a:valueMissing a:valueMissing"
+	if actual := strings.Join(names, " "); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+
+	doc.QuerySelectorAll("[type=radio]").Get(1).Checked(true)
+	c := form.QuerySelector("[name=c]")
+	c.Value("6")
+	form.QuerySelector("[name=b]").SetCustomValidity("taken")
+	names = nil
+	for _, c := range form.CheckValidity() {
+		names = append(names, c.Element.GetAttribute("name")+":"+c.Validity.String()+":"+c.Message)
+	}
+	expect = "b:customError:taken c:rangeOverflow:"
+	if actual := strings.Join(names, " "); actual != expect {
+		t.Errorf("\ngot : %s\nwant: %s\n", actual, expect)
+	}
+}